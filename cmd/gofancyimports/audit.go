@@ -0,0 +1,234 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"go.uber.org/multierr"
+
+	"github.com/NonLogicalDev/gofancyimports"
+	"github.com/NonLogicalDev/gofancyimports/pkg/organizer/autogroup"
+	"github.com/NonLogicalDev/gofancyimports/pkg/types"
+)
+
+type auditAliasesCMD struct {
+	*cobra.Command
+
+	writeFile bool
+	showDiff  bool
+	recursive bool
+
+	unify   bool
+	aliases []string
+}
+
+// aliasUsage lists files importing a path, by the name the path is imported under.
+type aliasUsage map[string][]string
+
+func makeAuditCommand() *cobra.Command {
+	cmdAudit := &cobra.Command{
+		Use:   "audit",
+		Short: "Report inconsistencies across multiple provided files",
+	}
+	cmdAudit.AddCommand(makeAuditAliasesCommand())
+	return cmdAudit
+}
+
+func makeAuditAliasesCommand() *cobra.Command {
+	cmdAliases := auditAliasesCMD{
+		Command: &cobra.Command{
+			Use:   "aliases [path...]",
+			Short: "Report (and unify) import paths imported under more than one name",
+			Args:  cobra.MinimumNArgs(1),
+		},
+	}
+	cmdAliases.Command.RunE = cmdAliases.runAudit
+
+	// Only the aliases and their references are rewritten, none of the regrouping flags of fix apply.
+	cmdAliases.PersistentFlags().BoolVarP(&cmdAliases.writeFile,
+		"write", "w", false,
+		"write the file back?")
+	cmdAliases.PersistentFlags().BoolVarP(&cmdAliases.showDiff,
+		"diff", "d", false,
+		"print diff")
+	cmdAliases.PersistentFlags().BoolVarP(&cmdAliases.recursive,
+		"recursive", "r", false,
+		"recurse into subdirectories when processing directories")
+	cmdAliases.PersistentFlags().BoolVar(&cmdAliases.unify,
+		"unify", false,
+		"rewrite imports to the most common (or configured) name and rename their references, printing a diff unless -w is given")
+	cmdAliases.PersistentFlags().StringArrayVar(&cmdAliases.aliases,
+		"alias", nil,
+		"name an import path is expected to be imported under (path=alias)")
+
+	return cmdAliases.Command
+}
+
+func (c *auditAliasesCMD) runAudit(cmd *cobra.Command, args []string) error {
+	configured := map[string]string{}
+	for _, entry := range c.aliases {
+		importPath, alias, ok := strings.Cut(entry, "=")
+		importPath, alias = strings.TrimSpace(importPath), strings.TrimSpace(alias)
+		if !ok || importPath == "" || !token.IsIdentifier(alias) || alias == "_" {
+			return fmt.Errorf("invalid alias %q: expected path=alias", entry)
+		}
+		configured[importPath] = alias
+	}
+
+	var (
+		errs    error
+		paths   []string
+		sources = map[string][]byte{}
+		names   = map[string]map[string]string{}
+		usages  = map[string]aliasUsage{}
+		fset    = token.NewFileSet()
+	)
+	for _, srcPath := range args {
+		discovered, err := discoverPaths(srcPath, c.recursive)
+		if err != nil {
+			errs = multierr.Append(errs, fmt.Errorf("failed to discover paths for %s: %w", srcPath, err))
+			continue
+		}
+
+		for _, path := range discovered {
+			src, err := os.ReadFile(path)
+			if err != nil {
+				errs = multierr.Append(errs, fmt.Errorf("failed reading file: %w", err))
+				continue
+			}
+			file, err := parser.ParseFile(fset, path, src, parser.SkipObjectResolution)
+			if err != nil {
+				errs = multierr.Append(errs, fmt.Errorf("failed parsing file: %w", err))
+				continue
+			}
+
+			paths = append(paths, path)
+			sources[path] = src
+			names[path] = autogroup.ImportNames(file)
+			for importPath, name := range names[path] {
+				if usages[importPath] == nil {
+					usages[importPath] = aliasUsage{}
+				}
+				usages[importPath][name] = append(usages[importPath][name], path)
+			}
+		}
+	}
+
+	// Names every inconsistently imported path is unified to.
+	targets := map[string]string{}
+	importPaths := make([]string, 0, len(usages))
+	for importPath := range usages {
+		importPaths = append(importPaths, importPath)
+	}
+	sort.Strings(importPaths)
+	for _, importPath := range importPaths {
+		usage := usages[importPath]
+		ranked := usage.ranked()
+
+		target, ok := configured[importPath]
+		if !ok {
+			target = ranked[0]
+		}
+		if len(ranked) == 1 && ranked[0] == target {
+			continue
+		}
+		targets[importPath] = target
+
+		fmt.Printf("%s: imported under inconsistent names, expected %s\n", importPath, target)
+		for _, name := range ranked {
+			fmt.Printf("\t%s (%s)\n", name, pluralFiles(len(usage[name])))
+			for _, path := range usage[name] {
+				fmt.Printf("\t\t%s\n", path)
+			}
+		}
+	}
+
+	if !c.unify {
+		return errs
+	}
+	for _, path := range paths {
+		aliases := map[string]string{}
+		for importPath, name := range names[path] {
+			if target, ok := targets[importPath]; ok && name != target {
+				aliases[importPath] = target
+			}
+		}
+		if len(aliases) == 0 {
+			continue
+		}
+
+		if err := c.unifyAliases(path, sources[path], aliases); err != nil {
+			errs = multierr.Append(errs, fmt.Errorf("failed unifying aliases: %w", err))
+		}
+	}
+	return errs
+}
+
+// unifyAliases sets the aliases of imports of the file and renames their references, leaving
+// the imports otherwise as they are. The file is written back with -w, otherwise the change is
+// printed as a diff.
+func (c *auditAliasesCMD) unifyAliases(srcPath string, srcOriginal []byte, aliases map[string]string) error {
+	var aliasErr error
+	srcRewritten, err := gofancyimports.RewriteImportsSource(
+		srcPath, srcOriginal,
+		gofancyimports.WithFileTransform(func(fset *token.FileSet, file *ast.File) types.ImportTransform {
+			aliasErr = autogroup.CheckImportAliases(file, aliases)
+			return autogroup.NewAliasTransform(
+				autogroup.WithFile(fset, file),
+				autogroup.WithImportAliases(aliases),
+			)
+		}),
+	)
+	if err != nil {
+		return fmt.Errorf("rewriting imports: %w", err)
+	}
+	if aliasErr != nil {
+		return fmt.Errorf("%s: %w", srcPath, aliasErr)
+	}
+	if bytes.Equal(srcOriginal, srcRewritten) {
+		return nil
+	}
+
+	if c.showDiff || !c.writeFile {
+		diff, err := generateDiff(srcPath, srcOriginal, srcRewritten)
+		if err != nil {
+			return fmt.Errorf("generating diff: %w", err)
+		}
+		fmt.Printf("%s", diff)
+		return nil
+	}
+	if err := os.WriteFile(srcPath, srcRewritten, 0o666); err != nil {
+		return err
+	}
+	fmt.Println("Written:", srcPath)
+	return nil
+}
+
+// ranked returns the names ordered by the number of files using them, most common first.
+func (u aliasUsage) ranked() []string {
+	names := make([]string, 0, len(u))
+	for name := range u {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if len(u[names[i]]) != len(u[names[j]]) {
+			return len(u[names[i]]) > len(u[names[j]])
+		}
+		return names[i] < names[j]
+	})
+	return names
+}
+
+func pluralFiles(n int) string {
+	if n == 1 {
+		return "1 file"
+	}
+	return fmt.Sprintf("%d files", n)
+}
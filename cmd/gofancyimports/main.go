@@ -17,6 +17,7 @@ import (
 	"go.uber.org/multierr"
 
 	"github.com/NonLogicalDev/gofancyimports"
+	"github.com/NonLogicalDev/gofancyimports/internal/astutils"
 	"github.com/NonLogicalDev/gofancyimports/pkg/organizer/autogroup"
 	"github.com/NonLogicalDev/gofancyimports/pkg/types"
 )
@@ -39,6 +40,53 @@ type fixCMD struct {
 	localPrefixes []string
 	groupEffect   bool
 	groupNoDot    bool
+	groupDot      bool
+	groupAlias    bool
+
+	groupX    bool
+	xPrefixes []string
+
+	groupInternal           bool
+	groupInternalModuleOnly bool
+
+	groupDepth        int
+	groupDepthMinSize int
+
+	groupTestPackage string
+	groupTestHelpers bool
+	testHelpers      []string
+
+	groupOrder []string
+
+	stickyPlacement string
+	stickySort      bool
+
+	commentPlacement string
+	dedupDocComments bool
+
+	collisionAliases  string
+	consistentAliases bool
+
+	layout             string
+	singleImportParens bool
+
+	addMissing bool
+	goVersion  string
+
+	migrateDeprecated bool
+	migrateSymbols    []string
+
+	pathMappings     []string
+	pathMappingFile  string
+	rewriteSelectors bool
+	pathMigrations   map[string]string
+
+	importAliases map[string]string
+
+	blankComments        []string
+	blankCommentDefaults bool
+
+	directiveImports bool
 }
 
 var cmdName = "gofancyimports"
@@ -58,17 +106,107 @@ func main() {
 	}
 	cmd.AddCommand(makeDebugCommand())
 	cmd.AddCommand(makeFixCommand())
+	cmd.AddCommand(makeMigrateCommand())
+	cmd.AddCommand(makeAliasCommand())
+	cmd.AddCommand(makeAuditCommand())
 
 	if err := cmd.Execute(); err != nil {
 		_, _ = fmt.Fprintf(os.Stderr, "Error:\n%s\n", err)
+		os.Exit(1)
 	}
 }
 
 func makeFixCommand() *cobra.Command {
-	cmdFix := fixCMD{
+	return newFixCMD("fix", "Fixup single or multiple provided files").Command
+}
+
+func makeMigrateCommand() *cobra.Command {
+	cmdMigrate := newFixCMD("migrate", "Rewrite import paths and regroup single or multiple provided files")
+	cmdMigrate.Command.RunE = func(cmd *cobra.Command, args []string) error {
+		migrations, err := parsePathMigrations(cmdMigrate.pathMappings, cmdMigrate.pathMappingFile)
+		if err != nil {
+			return err
+		}
+		if len(migrations) == 0 {
+			return fmt.Errorf("no import path mappings provided: use --map or --map-file")
+		}
+		cmdMigrate.pathMigrations = migrations
+		return cmdMigrate.RunE(cmd, args)
+	}
+
+	cmdMigrate.PersistentFlags().StringArrayVar(&cmdMigrate.pathMappings,
+		"map", nil,
+		"rewrite imports of a path and the packages nested under it (old/path=new/path)")
+	cmdMigrate.PersistentFlags().StringVar(&cmdMigrate.pathMappingFile,
+		"map-file", "",
+		"file with import path mappings, one per line (old/path=new/path or old/path new/path)")
+	cmdMigrate.PersistentFlags().BoolVar(&cmdMigrate.rewriteSelectors,
+		"rewrite-selectors", false,
+		"rename references to imports whose package name changes instead of aliasing them")
+
+	return cmdMigrate.Command
+}
+
+func makeAliasCommand() *cobra.Command {
+	cmdAlias := &cobra.Command{
+		Use:   "alias",
+		Short: "Manage aliases of imports",
+	}
+
+	cmdSet := newFixCMD("set <import-path> <alias> [path...]", "Set the alias of an import and rename its references in single or multiple provided files")
+	cmdSet.Command.Args = cobra.MinimumNArgs(2)
+	cmdSet.Command.RunE = func(cmd *cobra.Command, args []string) error {
+		importPath, alias := args[0], args[1]
+		if !token.IsIdentifier(alias) || alias == "_" {
+			return fmt.Errorf("invalid alias %q: expected an identifier", alias)
+		}
+		cmdSet.importAliases = map[string]string{importPath: alias}
+		return cmdSet.RunE(cmd, args[2:])
+	}
+	cmdAlias.AddCommand(cmdSet.Command)
+
+	return cmdAlias
+}
+
+// parsePathMigrations parses import path mappings given on the command line and in the
+// mapping file, where blank lines and lines starting with # are ignored.
+func parsePathMigrations(mappings []string, mappingFile string) (map[string]string, error) {
+	if mappingFile != "" {
+		content, err := os.ReadFile(mappingFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed reading mapping file: %w", err)
+		}
+		var fileMappings []string
+		for _, line := range strings.Split(string(content), "\n") {
+			line = strings.TrimSpace(line)
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			if fields := strings.Fields(line); len(fields) == 2 && !strings.Contains(line, "=") {
+				line = fields[0] + "=" + fields[1]
+			}
+			fileMappings = append(fileMappings, line)
+		}
+		mappings = append(fileMappings, mappings...)
+	}
+
+	migrations := map[string]string{}
+	for _, entry := range mappings {
+		from, to, ok := strings.Cut(entry, "=")
+		from, to = strings.TrimSpace(from), strings.TrimSpace(to)
+		if !ok || from == "" || to == "" {
+			return nil, fmt.Errorf("invalid import path mapping %q: expected old/path=new/path", entry)
+		}
+		migrations[from] = to
+	}
+	return migrations, nil
+}
+
+func newFixCMD(use string, short string) *fixCMD {
+	cmdFix := &fixCMD{
 		Command: &cobra.Command{
-			Use:   "fix",
-			Short: "Fixup single or multiple provided files",
+			Use:   use,
+			Short: short,
 		},
 
 		writeFile: false,
@@ -94,14 +232,95 @@ func makeFixCommand() *cobra.Command {
 	cmdFix.PersistentFlags().BoolVar(&cmdFix.groupNoDot,
 		"group-nodot", false,
 		"group no dot imports")
+	cmdFix.PersistentFlags().BoolVar(&cmdFix.groupDot,
+		"group-dot", false,
+		"group dot imports")
+	cmdFix.PersistentFlags().BoolVar(&cmdFix.groupAlias,
+		"group-alias", false,
+		"group aliased imports")
+	cmdFix.PersistentFlags().BoolVar(&cmdFix.groupX,
+		"group-x", false,
+		"group golang.org/x imports right after standard library")
+	cmdFix.PersistentFlags().StringSliceVar(&cmdFix.xPrefixes,
+		"x-prefixes", nil,
+		"import path prefixes of the x group overriding the default (golang.org/x/)")
+	cmdFix.PersistentFlags().BoolVar(&cmdFix.groupInternal,
+		"group-internal", false,
+		"group imports of internal packages")
+	cmdFix.PersistentFlags().BoolVar(&cmdFix.groupInternalModuleOnly,
+		"group-internal-module-only", false,
+		"only group imports of internal packages of the current module")
+	cmdFix.PersistentFlags().IntVar(&cmdFix.groupDepth,
+		"group-depth", 0,
+		"split third party imports into groups by the first N path elements (1: host, 2: host/org)")
+	cmdFix.PersistentFlags().IntVar(&cmdFix.groupDepthMinSize,
+		"group-depth-min-size", 2,
+		"minimum number of imports for a third party sub-group to be split out")
+	cmdFix.PersistentFlags().StringVar(&cmdFix.groupTestPackage,
+		"group-test-package", "inline",
+		"placement of the package under test import in external test packages (inline, leading, trailing)")
+	cmdFix.PersistentFlags().BoolVar(&cmdFix.groupTestHelpers,
+		"group-test-helpers", false,
+		"group imports of test helper packages in _test.go files")
+	cmdFix.PersistentFlags().StringSliceVar(&cmdFix.testHelpers,
+		"test-helpers", nil,
+		"test helper packages (path or path/... patterns) overriding the default list")
+	cmdFix.PersistentFlags().StringSliceVar(&cmdFix.groupOrder,
+		"group-order", nil,
+		"order of groups (std, x, nodot, thirdparty, testhelper, local, internal, effect, dot, alias), unlisted groups follow in default order")
+	cmdFix.PersistentFlags().StringVar(&cmdFix.stickyPlacement,
+		"sticky-placement", "after",
+		"placement of doc commented groups relative to organized groups (after, before, interleaved)")
+	cmdFix.PersistentFlags().BoolVar(&cmdFix.stickySort,
+		"sticky-sort", true,
+		"sort imports inside doc commented groups")
+	cmdFix.PersistentFlags().StringVar(&cmdFix.commentPlacement,
+		"comments", "hoist",
+		"placement of comments floating between imports (hoist, inside, attach)")
+	cmdFix.PersistentFlags().BoolVar(&cmdFix.dedupDocComments,
+		"dedup-doc-comments", false,
+		"remove repeated comments from doc comments of merged import blocks")
+	cmdFix.PersistentFlags().StringVar(&cmdFix.collisionAliases,
+		"collision-aliases", "off",
+		"alias imports with colliding package names and rename their references (off, initial, parent)")
+	cmdFix.PersistentFlags().BoolVar(&cmdFix.consistentAliases,
+		"consistent-aliases", false,
+		"import every path under the name most files of the package use and rename its references")
+	cmdFix.PersistentFlags().StringVar(&cmdFix.layout,
+		"layout", "merge-undocumented",
+		"import declaration layout (merge-undocumented, merge-all, one-per-group)")
+	cmdFix.PersistentFlags().BoolVar(&cmdFix.singleImportParens,
+		"single-import-parens", false,
+		"write declarations with a single import with parenthesis")
 	cmdFix.PersistentFlags().BoolVar(&cmdFix.groupEffect,
 		"group-effect", false,
 		"group side effect imports")
+	cmdFix.PersistentFlags().BoolVar(&cmdFix.addMissing,
+		"add-missing", false,
+		"add missing imports (standard library and packages visible to the module)")
+	cmdFix.PersistentFlags().StringVar(&cmdFix.goVersion,
+		"go", "",
+		"go version to resolve missing imports and migrate symbols for (default: go directive of the closest go.mod)")
+	cmdFix.PersistentFlags().BoolVar(&cmdFix.migrateDeprecated,
+		"migrate-deprecated", false,
+		"rewrite references to deprecated standard library symbols (e.g. ioutil.ReadFile to os.ReadFile)")
+	cmdFix.PersistentFlags().StringArrayVar(&cmdFix.migrateSymbols,
+		"migrate-symbol", nil,
+		"rewrite references to a symbol into references to its replacement (old/path.Name=new/path.Name)")
+	cmdFix.PersistentFlags().StringArrayVar(&cmdFix.blankComments,
+		"blank-comment", nil,
+		"default justification comment for side effect imports of a package (path=comment)")
+	cmdFix.PersistentFlags().BoolVar(&cmdFix.blankCommentDefaults,
+		"blank-comment-defaults", false,
+		"add default justification comments to side effect imports of well known packages (pprof, tzdata, database drivers, ...)")
+	cmdFix.PersistentFlags().BoolVar(&cmdFix.directiveImports,
+		"directive-imports", false,
+		"manage embed and unsafe imports required by go:embed and go:linkname directives")
 	cmdFix.PersistentFlags().BoolVarP(&cmdFix.recursive,
 		"recursive", "r", false,
 		"recurse into subdirectories when processing directories")
 
-	return cmdFix.Command
+	return cmdFix
 }
 
 func (c *fixCMD) RunE(cmd *cobra.Command, args []string) error {
@@ -120,6 +339,10 @@ func (c *fixCMD) RunE(cmd *cobra.Command, args []string) error {
 		return errs
 	}
 
+	if c.consistentAliases {
+		return c.runPackages(args)
+	}
+
 	for _, srcPath := range args {
 		paths, err := discoverPaths(srcPath, c.recursive)
 		if err != nil {
@@ -142,7 +365,58 @@ func (c *fixCMD) RunE(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	return nil
+	return errs
+}
+
+// runPackages rewrites discovered files package by package, grouping the files by their
+// directory and package clause (external test packages are separate packages).
+func (c *fixCMD) runPackages(args []string) error {
+	type pkg struct {
+		paths []string
+		srcs  [][]byte
+	}
+
+	var (
+		errs error
+		keys []string
+		pkgs = map[string]*pkg{}
+		fset = token.NewFileSet()
+	)
+	for _, srcPath := range args {
+		paths, err := discoverPaths(srcPath, c.recursive)
+		if err != nil {
+			errs = multierr.Append(errs, fmt.Errorf("failed to discover paths for %s: %w", srcPath, err))
+			continue
+		}
+
+		for _, path := range paths {
+			srcOriginal, err := os.ReadFile(path)
+			if err != nil {
+				errs = multierr.Append(errs, fmt.Errorf("failed reading file: %w", err))
+				continue
+			}
+			file, err := parser.ParseFile(fset, path, srcOriginal, parser.PackageClauseOnly)
+			if err != nil {
+				errs = multierr.Append(errs, fmt.Errorf("failed parsing file: %w", err))
+				continue
+			}
+
+			key := filepath.Dir(path) + " " + file.Name.Name
+			if pkgs[key] == nil {
+				pkgs[key] = &pkg{}
+				keys = append(keys, key)
+			}
+			pkgs[key].paths = append(pkgs[key].paths, path)
+			pkgs[key].srcs = append(pkgs[key].srcs, srcOriginal)
+		}
+	}
+
+	for _, key := range keys {
+		if err := c.runPackageRewrite(pkgs[key].paths, pkgs[key].srcs); err != nil {
+			errs = multierr.Append(errs, fmt.Errorf("failed fixing package: %w", err))
+		}
+	}
+	return errs
 }
 
 // discoverPaths returns a list of Go file paths from the given path.
@@ -193,6 +467,84 @@ func discoverPaths(path string, recursive bool) ([]string, error) {
 }
 
 func (c *fixCMD) runRewrite(srcPath string, srcOriginal []byte, isStdin bool) error {
+	transformOpts, err := c.transformOptions(srcPath, isStdin)
+	if err != nil {
+		return err
+	}
+
+	var checkErr error
+	srcRewritten, err := gofancyimports.RewriteImportsSource(
+		srcPath, srcOriginal,
+		gofancyimports.WithFileTransform(func(fset *token.FileSet, file *ast.File) types.ImportTransform {
+			if checkErr == nil {
+				checkErr = autogroup.CheckGroupDirectives(file)
+			}
+			if len(c.importAliases) > 0 && checkErr == nil {
+				checkErr = autogroup.CheckImportAliases(file, c.importAliases)
+			}
+			fileOpts := append([]autogroup.Option{autogroup.WithFile(fset, file)}, transformOpts...)
+			if c.addMissing {
+				fileOpts = append(fileOpts, c.resolverOptions(srcPath, file, isStdin)...)
+			}
+			fileOpts = append(fileOpts, c.packageTypesOptions(srcPath, file, isStdin)...)
+			return autogroup.New(fileOpts...)
+		}),
+	)
+	if err != nil {
+		return fmt.Errorf("rewriting imports: %w", err)
+	}
+	if checkErr != nil {
+		return fmt.Errorf("%s: %w", srcPath, checkErr)
+	}
+
+	return c.writeResult(srcPath, srcOriginal, srcRewritten, isStdin)
+}
+
+// runPackageRewrite rewrites the files of a single package at once, as required by options
+// operating across the files of the package.
+func (c *fixCMD) runPackageRewrite(srcPaths []string, srcOriginals [][]byte) error {
+	transformOpts, err := c.transformOptions(srcPaths[0], false)
+	if err != nil {
+		return err
+	}
+	transformOpts = append(transformOpts, autogroup.WithConsistentAliases(c.consistentAliases))
+
+	checkErrs := map[string]error{}
+	fileOpts := func(file types.PackageFile) []autogroup.Option {
+		if err := autogroup.CheckGroupDirectives(file.File); err != nil {
+			checkErrs[file.Filename] = err
+		} else if len(c.importAliases) > 0 {
+			if err := autogroup.CheckImportAliases(file.File, c.importAliases); err != nil {
+				checkErrs[file.Filename] = err
+			}
+		}
+		opts := c.packageTypesOptions(file.Filename, file.File, false)
+		if c.addMissing {
+			opts = append(opts, c.resolverOptions(file.Filename, file.File, false)...)
+		}
+		return opts
+	}
+	srcsRewritten, err := gofancyimports.RewritePackageImportsSource(
+		srcPaths, srcOriginals,
+		autogroup.NewPackage(fileOpts, transformOpts...),
+	)
+	if err != nil {
+		return fmt.Errorf("rewriting imports: %w", err)
+	}
+
+	var errs error
+	for i, srcPath := range srcPaths {
+		if err := checkErrs[srcPath]; err != nil {
+			errs = multierr.Append(errs, fmt.Errorf("%s: %w", srcPath, err))
+			continue
+		}
+		errs = multierr.Append(errs, c.writeResult(srcPath, srcOriginals[i], srcsRewritten[i], false))
+	}
+	return errs
+}
+
+// transformOptions returns the options of the organizer configured by the flags.
+func (c *fixCMD) transformOptions(srcPath string, isStdin bool) ([]autogroup.Option, error) {
 	// Expand comma-separated values in localPrefixes
 	var expandedPrefixes []string
 	for _, prefix := range c.localPrefixes {
@@ -205,20 +557,112 @@ func (c *fixCMD) runRewrite(srcPath string, srcOriginal []byte, isStdin bool) er
 		}
 	}
 
-	transform := autogroup.New(
-		autogroup.WithSpecFixups(autogroup.FixupEmbedPackage),
+	blankComments, err := autogroup.BlankImportComments(c.blankCommentDefaults, c.blankComments)
+	if err != nil {
+		return nil, err
+	}
+
+	transformOpts := []autogroup.Option{
+		autogroup.WithSpecFixups(autogroup.FixupBlankImportComments(blankComments)),
 		autogroup.WithNoDotGroupEnabled(c.groupNoDot),
 		autogroup.WithSideEffectGroupEnabled(c.groupEffect),
 		autogroup.WithLocalPrefixGroup(expandedPrefixes),
+		autogroup.WithDirectiveImports(c.directiveImports),
+		autogroup.WithDotImportGroup(c.groupDot),
+		autogroup.WithAliasGroup(c.groupAlias),
+		autogroup.WithExtendedStdlibGroup(c.groupX),
+		autogroup.WithInternalGroup(c.groupInternal),
+		autogroup.WithGroupByPathDepth(c.groupDepth),
+		autogroup.WithGroupByPathMinSize(c.groupDepthMinSize),
+	}
+	stickyPlacement, ok := autogroup.ParseStickyGroupPlacement(c.stickyPlacement)
+	if !ok {
+		return nil, fmt.Errorf("invalid sticky group placement %q: expected after, before or interleaved", c.stickyPlacement)
+	}
+	transformOpts = append(transformOpts,
+		autogroup.WithStickyGroupPlacement(stickyPlacement),
+		autogroup.WithStickyGroupSorting(c.stickySort),
 	)
-	srcRewritten, err := gofancyimports.RewriteImportsSource(
-		srcPath, srcOriginal,
-		gofancyimports.WithTransform(transform),
+	commentPlacement, ok := autogroup.ParseCommentPlacement(c.commentPlacement)
+	if !ok {
+		return nil, fmt.Errorf("invalid comment placement %q: expected hoist, inside or attach", c.commentPlacement)
+	}
+	transformOpts = append(transformOpts,
+		autogroup.WithCommentPlacement(commentPlacement),
+		autogroup.WithDocCommentDedup(c.dedupDocComments),
 	)
-	if err != nil {
-		return fmt.Errorf("rewriting imports: %w", err)
+	aliasScheme, ok := autogroup.ParseAliasScheme(c.collisionAliases)
+	if !ok {
+		return nil, fmt.Errorf("invalid collision alias scheme %q: expected off, initial or parent", c.collisionAliases)
+	}
+	transformOpts = append(transformOpts, autogroup.WithCollisionAliases(aliasScheme))
+	layout, ok := autogroup.ParseDeclarationLayout(c.layout)
+	if !ok {
+		return nil, fmt.Errorf("invalid layout %q: expected merge-undocumented, merge-all or one-per-group", c.layout)
+	}
+	transformOpts = append(transformOpts, autogroup.WithDeclarationLayout(layout))
+	if c.singleImportParens {
+		transformOpts = append(transformOpts, autogroup.WithSingleImportStyle(autogroup.SingleImportParenthesized))
+	}
+	placement, ok := autogroup.ParsePackageUnderTestPlacement(c.groupTestPackage)
+	if !ok {
+		return nil, fmt.Errorf("invalid package under test placement %q: expected inline, leading or trailing", c.groupTestPackage)
+	}
+	transformOpts = append(transformOpts,
+		autogroup.WithPackageUnderTestGroup(placement),
+		autogroup.WithTestHelperGroup(c.groupTestHelpers),
+	)
+	if len(c.xPrefixes) > 0 {
+		transformOpts = append(transformOpts, autogroup.WithExtendedStdlibPrefixes(c.xPrefixes))
+	}
+	if len(c.testHelpers) > 0 {
+		transformOpts = append(transformOpts, autogroup.WithTestHelperPackages(c.testHelpers))
+	}
+	if !isStdin {
+		transformOpts = append(transformOpts, autogroup.WithPackagePath(packageImportPath(srcPath)))
+	}
+	if c.groupInternalModuleOnly && !isStdin {
+		transformOpts = append(transformOpts, autogroup.WithInternalGroupModule(modulePath(srcPath)))
+	}
+	if len(c.pathMigrations) > 0 {
+		transformOpts = append(transformOpts, autogroup.WithImportPathMigrations(c.pathMigrations, c.rewriteSelectors))
+	}
+	if c.migrateDeprecated || len(c.migrateSymbols) > 0 {
+		migrations := map[string]string{}
+		if c.migrateDeprecated {
+			for from, to := range autogroup.DefaultStdlibMigrations {
+				migrations[from] = to
+			}
+		}
+		for _, entry := range c.migrateSymbols {
+			from, to, ok := strings.Cut(entry, "=")
+			if !ok {
+				return nil, fmt.Errorf("invalid symbol migration %q: expected old/path.Name=new/path.Name", entry)
+			}
+			migrations[strings.TrimSpace(from)] = strings.TrimSpace(to)
+		}
+
+		goVersion := c.goVersion
+		if goVersion == "" && !isStdin {
+			goVersion = moduleGoVersion(srcPath)
+		}
+		transformOpts = append(transformOpts, autogroup.WithSymbolMigrations(goVersion, migrations))
+	}
+	if len(c.importAliases) > 0 {
+		transformOpts = append(transformOpts, autogroup.WithImportAliases(c.importAliases))
+	}
+	if len(c.groupOrder) > 0 {
+		order, err := autogroup.ParseGroupOrder(c.groupOrder)
+		if err != nil {
+			return nil, err
+		}
+		transformOpts = append(transformOpts, autogroup.WithGroupOrder(order...))
 	}
+	return transformOpts, nil
+}
 
+// writeResult prints, diffs or writes back the rewritten source as requested by the flags.
+func (c *fixCMD) writeResult(srcPath string, srcOriginal []byte, srcRewritten []byte, isStdin bool) error {
 	// Print diff.
 	if c.showDiff {
 		diff, err := generateDiff(srcPath, srcOriginal, srcRewritten)
@@ -243,7 +687,7 @@ func (c *fixCMD) runRewrite(srcPath string, srcOriginal []byte, isStdin bool) er
 
 	// Write back.
 	if !bytes.Equal(srcOriginal, srcRewritten) {
-		err = os.WriteFile(srcPath, srcRewritten, 0x666)
+		err := os.WriteFile(srcPath, srcRewritten, 0x666)
 		if err != nil {
 			return err
 		}
@@ -253,6 +697,54 @@ func (c *fixCMD) runRewrite(srcPath string, srcOriginal []byte, isStdin bool) er
 	return nil
 }
 
+// resolverOptions returns options for resolving missing imports of the file. References are
+// resolved from imports of sibling files in the package first, then from the standard library,
+// and finally from packages visible to the module of the file.
+func (c *fixCMD) resolverOptions(srcPath string, file *ast.File, isStdin bool) []autogroup.Option {
+	goVersion := c.goVersion
+	if goVersion == "" && !isStdin {
+		goVersion = moduleGoVersion(srcPath)
+	}
+	if isStdin {
+		return []autogroup.Option{
+			autogroup.WithMissingImportResolvers(autogroup.ResolveStdlibImports(goVersion)),
+		}
+	}
+
+	var (
+		siblings        = siblingFiles(srcPath, file.Name.Name)
+		siblingDeclared []string
+		siblingImports  []*ast.ImportSpec
+	)
+	for _, sibling := range siblings {
+		siblingDeclared = append(siblingDeclared, astutils.DeclaredNames(sibling)...)
+		siblingImports = append(siblingImports, sibling.Imports...)
+	}
+
+	resolvers := []autogroup.ImportResolver{
+		autogroup.ResolveFromImports(siblingImports),
+		autogroup.ResolveStdlibImports(goVersion),
+	}
+	if idx, ok := moduleIndex(srcPath); ok {
+		resolvers = append(resolvers, idx.Resolver(filepath.Dir(srcPath), fileImportPaths(siblings)))
+	}
+
+	return []autogroup.Option{
+		autogroup.WithPackageDeclarations(siblingDeclared),
+		autogroup.WithMissingImportResolvers(resolvers...),
+	}
+}
+
+// packageTypesOptions returns type information of the packages imported by the file, read
+// from the packages visible to its module, so that import name collisions can be resolved
+// without assuming package names and exported symbols.
+func (c *fixCMD) packageTypesOptions(srcPath string, file *ast.File, isStdin bool) []autogroup.Option {
+	if aliasScheme, _ := autogroup.ParseAliasScheme(c.collisionAliases); aliasScheme == nil || isStdin {
+		return nil
+	}
+	return []autogroup.Option{autogroup.WithPackageTypes(modulePackageTypes(srcPath, file))}
+}
+
 func (c *debugCMD) RunE(cmd *cobra.Command, args []string) error {
 	var errs error
 	if len(args) == 0 {
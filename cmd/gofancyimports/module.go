@@ -0,0 +1,151 @@
+package main
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/NonLogicalDev/gofancyimports/internal/modindex"
+)
+
+// moduleGoVersion returns the version from the `go` directive of the module
+// containing the provided source file, or empty string if it can't be determined.
+func moduleGoVersion(srcPath string) string {
+	modPath, ok := modindex.FindGoMod(filepath.Dir(srcPath))
+	if !ok {
+		return ""
+	}
+	return modindex.GoVersion(modPath)
+}
+
+// modulePath returns the path of the module containing the provided source file,
+// or empty string if it can't be determined.
+func modulePath(srcPath string) string {
+	modPath, ok := modindex.FindGoMod(filepath.Dir(srcPath))
+	if !ok {
+		return ""
+	}
+	return modindex.ModulePath(modPath)
+}
+
+// packageImportPath returns the import path of the package in the directory of the provided
+// source file, or empty string if it can't be determined.
+func packageImportPath(srcPath string) string {
+	modPath, ok := modindex.FindGoMod(filepath.Dir(srcPath))
+	if !ok {
+		return ""
+	}
+	module := modindex.ModulePath(modPath)
+	if module == "" {
+		return ""
+	}
+
+	srcDir, err := filepath.Abs(filepath.Dir(srcPath))
+	if err != nil {
+		return ""
+	}
+	rel, err := filepath.Rel(filepath.Dir(modPath), srcDir)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return ""
+	}
+	if rel == "." {
+		return module
+	}
+	return module + "/" + filepath.ToSlash(rel)
+}
+
+// moduleIndex returns the index of packages visible to the module containing the
+// provided source file.
+func moduleIndex(srcPath string) (*modindex.Index, bool) {
+	modPath, ok := modindex.FindGoMod(filepath.Dir(srcPath))
+	if !ok {
+		return nil, false
+	}
+	idx, err := modindex.LoadCached(modPath)
+	if err != nil {
+		return nil, false
+	}
+	return idx, true
+}
+
+// modulePackageTypes returns type information of the packages imported by the file that are
+// visible to the module containing the provided source file.
+func modulePackageTypes(srcPath string, file *ast.File) map[string]*types.Package {
+	idx, ok := moduleIndex(srcPath)
+	if !ok {
+		return nil
+	}
+	return idx.PackageTypes(fileImportPaths([]*ast.File{file}))
+}
+
+// dirFile is a go file parsed from a directory.
+type dirFile struct {
+	name string
+	file *ast.File
+}
+
+var (
+	_dirFilesMu sync.Mutex
+	_dirFiles   = map[string][]dirFile{}
+)
+
+// siblingFiles returns parsed sibling files of the provided source file belonging
+// to the same package.
+func siblingFiles(srcPath string, pkgName string) []*ast.File {
+	var files []*ast.File
+	for _, f := range parsedDirFiles(filepath.Dir(srcPath)) {
+		if f.name != filepath.Base(srcPath) && f.file.Name.Name == pkgName {
+			files = append(files, f.file)
+		}
+	}
+	return files
+}
+
+// parsedDirFiles returns parsed go files of the directory, every directory is parsed once
+// and reused for all files processed in it.
+func parsedDirFiles(dir string) []dirFile {
+	_dirFilesMu.Lock()
+	defer _dirFilesMu.Unlock()
+
+	if files, ok := _dirFiles[dir]; ok {
+		return files
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	var files []dirFile
+	fset := token.NewFileSet()
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".go") {
+			continue
+		}
+		file, err := parser.ParseFile(fset, filepath.Join(dir, entry.Name()), nil, parser.SkipObjectResolution)
+		if err != nil {
+			continue
+		}
+		files = append(files, dirFile{name: entry.Name(), file: file})
+	}
+	_dirFiles[dir] = files
+	return files
+}
+
+// fileImportPaths returns import paths of all provided files.
+func fileImportPaths(files []*ast.File) []string {
+	var paths []string
+	for _, file := range files {
+		for _, spec := range file.Imports {
+			if p, err := strconv.Unquote(spec.Path.Value); err == nil {
+				paths = append(paths, p)
+			}
+		}
+	}
+	return paths
+}
@@ -7,6 +7,7 @@ import (
 	"go/parser"
 	"go/printer"
 	"go/token"
+	"sort"
 	"strings"
 
 	"golang.org/x/tools/go/analysis"
@@ -18,10 +19,15 @@ import (
 
 type (
 	rewriteConfig struct {
-		transform  types.ImportTransform
-		printerCfg *printer.Config
+		transform     types.ImportTransform
+		fileTransform FileTransform
+		printerCfg    *printer.Config
 	}
 
+	// FileTransform constructs an import transform for a specific file, allowing the
+	// transform to inspect the contents of the file beyond its import declarations.
+	FileTransform func(fset *token.FileSet, file *ast.File) types.ImportTransform
+
 	Option func(opt *rewriteConfig)
 )
 
@@ -56,6 +62,25 @@ func WithTransform(transform types.ImportTransform) Option {
 	}
 }
 
+// WithFileTransform allows overriding the import group transform with one that is
+// constructed for every rewritten file. Takes precedence over [WithTransform].
+//
+// Example:
+//
+//	gofancyimports.WithFileTransform(func(fset *token.FileSet, file *ast.File) types.ImportTransform {
+//		return autogroup.New(
+//			autogroup.WithFile(fset, file),
+//			autogroup.WithMissingImportResolvers(autogroup.ResolveStdlibImports("")),
+//		)
+//	})
+func WithFileTransform(transform FileTransform) Option {
+	return func(cfg *rewriteConfig) {
+		if transform != nil {
+			cfg.fileTransform = transform
+		}
+	}
+}
+
 // RewriteImportsSource takes a filename and source and rewrite options and applies import transforms to the file.
 //
 // Consult the [WithTransform] function for a complete usage example.
@@ -74,9 +99,7 @@ func RewriteImportsSource(filename string, src []byte, opts ...Option) ([]byte,
 		return src, nil
 	}
 
-	// Import rewriter only returns one edit.
-	edit := edits[0]
-	output := ApplyTextEdit(fset, node, src, edit)
+	output := ApplyTextEdits(fset, node, src, edits)
 	return output, err
 }
 
@@ -86,7 +109,9 @@ func RewriteImportsSource(filename string, src []byte, opts ...Option) ([]byte,
 //
 // In most cases [RewriteImportsSource] is a much more ergonomic batteries-included alternative.
 //
-// Contract: This functions will only ever return a single text edit.
+// Contract: This function returns at most one text edit rewriting the import declarations,
+// along with edits renaming identifiers outside of them as requested by the transform (see
+// [types.ImportDeclaration.IdentRenames]). Edits are sorted by position and never overlap.
 //
 // Consult the [WithTransform] function for a complete usage example.
 func RewriteImportsAST(fset *token.FileSet, node *ast.File, src []byte, opts ...Option) ([]*analysis.TextEdit, error) {
@@ -97,11 +122,17 @@ func RewriteImportsAST(fset *token.FileSet, node *ast.File, src []byte, opts ...
 	for _, apply := range opts {
 		apply(&config)
 	}
+	if config.fileTransform != nil {
+		config.transform = config.fileTransform(fset, node)
+	}
 
 	importDeclRange, err := ParseImportDeclarations(fset, node)
 	if err != nil {
 		return nil, fmt.Errorf("while gathering declarations: %w", err)
 	}
+	if importDeclRange.Disabled {
+		return nil, nil
+	}
 
 	// if there are no imports all together we shall insert our imports at a character right after package name.
 	if importDeclRange.Pos == token.NoPos {
@@ -119,27 +150,26 @@ func RewriteImportsAST(fset *token.FileSet, node *ast.File, src []byte, opts ...
 		addPaddingRight string
 		endOffset       = f.Offset(importDeclRange.End)
 	)
-	if startOffset-1 > 0 && startOffset-1 < len(src) && src[startOffset-1] != '\n' {
-		addPaddingLeft += "\n"
+	if n := countNewlinesBefore(src, startOffset); startOffset > 0 && n < 2 {
+		addPaddingLeft = strings.Repeat("\n", 2-n)
 	}
-	if startOffset-2 > 0 && startOffset-2 < len(src) && src[startOffset-1] != '\n' {
-		addPaddingLeft += "\n"
-	}
-	if endOffset+1 > 0 && endOffset+1 < len(src) && src[endOffset+1] != '\n' {
-		addPaddingRight += "\n"
-	}
-	if endOffset+2 > 0 && endOffset+2 < len(src) && src[endOffset+1] != '\n' {
-		addPaddingRight += "\n"
+	if n := countNewlinesAfter(src, endOffset); endOffset+n < len(src) && n < 2 {
+		addPaddingRight = strings.Repeat("\n", 2-n)
 	}
 
 	transformedDecls := config.transform(importDeclRange.Statements)
-	importDecls, newLines, newImportDeclRangeEnd := buildImportDecls(importDeclRange.Pos, transformedDecls)
+	importDecls, newLines, newImportDeclRangeEnd, floatingComments := buildImportDecls(importDeclRange.Pos, transformedDecls, func(pos token.Pos) int {
+		if !pos.IsValid() || int(pos) < f.Base() || int(pos) > f.Base()+f.Size() {
+			return 0
+		}
+		return f.Line(pos)
+	})
 
 	var importString string
 	if importDecls != nil && newImportDeclRangeEnd != 0 {
 		var err error
 		importString, err = printImportDecls(
-			f.Base(), int(newImportDeclRangeEnd)-f.Base(), newLines, importDecls, config.printerCfg,
+			f.Base(), int(newImportDeclRangeEnd)-f.Base(), newLines, importDecls, floatingComments, config.printerCfg,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("while serializing re-written import declarations: %w", err)
@@ -147,16 +177,122 @@ func RewriteImportsAST(fset *token.FileSet, node *ast.File, src []byte, opts ...
 	}
 	if importString != "" {
 		importString = addPaddingLeft + importString + addPaddingRight
+	} else if importDeclRange.End > importDeclRange.Pos {
+		// All imports were removed, also remove the blank lines that separated them from the code.
+		importDeclRange.End += token.Pos(countNewlinesAfter(src, endOffset))
 	}
+	var edits []*analysis.TextEdit
 	importStringOriginal := string(src[f.Offset(importDeclRange.Pos):f.Offset(importDeclRange.End)])
-	if importString == importStringOriginal {
-		return nil, nil
+	if importString != importStringOriginal {
+		edits = append(edits, &analysis.TextEdit{
+			Pos:     importDeclRange.Pos,
+			End:     importDeclRange.End,
+			NewText: []byte(importString),
+		})
+	}
+	edits = append(edits, identRenameEdits(transformedDecls)...)
+	sort.Slice(edits, func(i, j int) bool {
+		return edits[i].Pos < edits[j].Pos
+	})
+	return edits, nil
+}
+
+// RewritePackageImportsSource takes filenames and sources of the files of a single package and
+// applies the package transform to the files, returning the rewritten sources in the order of
+// files. Options overriding the transform ([WithTransform], [WithFileTransform]) are ignored.
+func RewritePackageImportsSource(filenames []string, srcs [][]byte, transform types.PackageTransform, opts ...Option) ([][]byte, error) {
+	fset := token.NewFileSet()
+	nodes := make([]*ast.File, len(filenames))
+	for i, filename := range filenames {
+		node, err := parser.ParseFile(fset, filename, srcs[i], parser.ParseComments)
+		if err != nil {
+			return nil, fmt.Errorf("failed parsing file: %w", err)
+		}
+		nodes[i] = node
+	}
+
+	edits, err := RewritePackageImportsAST(fset, nodes, srcs, transform, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed rewriting AST: %w", err)
+	}
+
+	outputs := make([][]byte, len(nodes))
+	for i, node := range nodes {
+		outputs[i] = srcs[i]
+		if len(edits[i]) > 0 {
+			outputs[i] = ApplyTextEdits(fset, node, srcs[i], edits[i])
+		}
 	}
-	return []*analysis.TextEdit{{
-		Pos:     importDeclRange.Pos,
-		End:     importDeclRange.End,
-		NewText: []byte(importString),
-	}}, nil
+	return outputs, nil
+}
+
+// RewritePackageImportsAST is the counterpart of [RewriteImportsAST] for the files of a single
+// package, applying the package transform to all of them at once. It returns the edits of every
+// file in the order of files, following the contract of [RewriteImportsAST].
+func RewritePackageImportsAST(fset *token.FileSet, nodes []*ast.File, srcs [][]byte, transform types.PackageTransform, opts ...Option) ([][]*analysis.TextEdit, error) {
+	files := make([]types.PackageFile, len(nodes))
+	for i, node := range nodes {
+		importDeclRange, err := ParseImportDeclarations(fset, node)
+		if err != nil {
+			return nil, fmt.Errorf("while gathering declarations of %s: %w", fset.File(node.Package).Name(), err)
+		}
+		files[i] = types.PackageFile{
+			Filename: fset.File(node.Package).Name(),
+			File:     node,
+			Decls:    importDeclRange.Statements,
+			Disabled: importDeclRange.Disabled,
+		}
+	}
+
+	transformedDecls := transform(fset, files)
+	if len(transformedDecls) != len(files) {
+		return nil, fmt.Errorf("package transform returned declarations of %d files, expected %d", len(transformedDecls), len(files))
+	}
+
+	edits := make([][]*analysis.TextEdit, len(nodes))
+	for i, node := range nodes {
+		decls := transformedDecls[i]
+		fileOpts := append(append([]Option{}, opts...), func(cfg *rewriteConfig) {
+			cfg.fileTransform = nil
+			cfg.transform = func([]types.ImportDeclaration) []types.ImportDeclaration {
+				return decls
+			}
+		})
+		fileEdits, err := RewriteImportsAST(fset, node, srcs[i], fileOpts...)
+		if err != nil {
+			return nil, fmt.Errorf("while rewriting %s: %w", files[i].Filename, err)
+		}
+		edits[i] = fileEdits
+	}
+	return edits, nil
+}
+
+// identRenameEdits returns edits renaming identifiers as requested by the declarations.
+func identRenameEdits(decls []types.ImportDeclaration) []*analysis.TextEdit {
+	var edits []*analysis.TextEdit
+	for _, d := range decls {
+		for ident, name := range d.IdentRenames {
+			if ident.Name == name {
+				continue
+			}
+			edits = append(edits, &analysis.TextEdit{
+				Pos:     ident.Pos(),
+				End:     ident.End(),
+				NewText: []byte(name),
+			})
+		}
+	}
+	return edits
+}
+
+// ApplyTextEdits applies text edits sorted by position to the source (for use in conjunction
+// with RewriteImportsAST).
+func ApplyTextEdits(fset *token.FileSet, node *ast.File, src []byte, edits []*analysis.TextEdit) []byte {
+	output := src
+	for i := len(edits) - 1; i >= 0; i-- {
+		output = ApplyTextEdit(fset, node, output, edits[i])
+	}
+	return output
 }
 
 // ApplyTextEdit applies a single text edit to the source (for use in conjunction with RewriteImportsAST)
@@ -175,11 +311,28 @@ func ApplyTextEdit(fset *token.FileSet, node *ast.File, src []byte, edit *analys
 	return output
 }
 
+// countNewlinesBefore returns the number of consecutive newlines (up to two) directly preceding the offset.
+func countNewlinesBefore(src []byte, offset int) (n int) {
+	for n < 2 && offset-n-1 >= 0 && offset-n-1 < len(src) && src[offset-n-1] == '\n' {
+		n++
+	}
+	return n
+}
+
+// countNewlinesAfter returns the number of consecutive newlines (up to two) starting at the offset.
+func countNewlinesAfter(src []byte, offset int) (n int) {
+	for n < 2 && offset+n >= 0 && offset+n < len(src) && src[offset+n] == '\n' {
+		n++
+	}
+	return n
+}
+
 func printImportDecls(
 	importBase int,
 	importSize int,
 	newLines []token.Pos,
 	importDecls []ast.Decl,
+	floatingComments []*ast.CommentGroup,
 	printerCfg *printer.Config,
 ) (string, error) {
 	if len(importDecls) == 0 {
@@ -211,6 +364,12 @@ func printImportDecls(
 		}
 		return true
 	})
+	if len(floatingComments) > 0 {
+		fileNode.Comments = append(fileNode.Comments, floatingComments...)
+		sort.Slice(fileNode.Comments, func(i, j int) bool {
+			return fileNode.Comments[i].Pos() < fileNode.Comments[j].Pos()
+		})
+	}
 
 	b := bytes.NewBuffer(nil)
 	err := printerCfg.Fprint(b, fset, fileNode)
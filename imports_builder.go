@@ -9,35 +9,53 @@ import (
 	"github.com/NonLogicalDev/gofancyimports/pkg/types"
 )
 
-func buildImportDecls(offset token.Pos, decls []types.ImportDeclaration) ([]ast.Decl, []token.Pos, token.Pos) {
+// buildImportDecls builds AST declarations from import declarations, returning the declarations,
+// positions of newlines, the end offset and comments floating inside the declarations which are
+// not reachable from the declaration nodes.
+//
+// The lineOf function reports source lines of original comment positions, allowing blank lines
+// separating comments of declaration doc comments to be preserved.
+func buildImportDecls(offset token.Pos, decls []types.ImportDeclaration, lineOf func(token.Pos) int) ([]ast.Decl, []token.Pos, token.Pos, []*ast.CommentGroup) {
 	var astDecls []ast.Decl
 	if len(decls) == 0 {
-		return nil, nil, 0
+		return nil, nil, 0, nil
 	}
 
-	var newLines []token.Pos
+	var (
+		newLines         []token.Pos
+		floatingComments []*ast.CommentGroup
+	)
 	for _, d := range decls {
-		decl, nl, newOffset := buildImportDecl(offset, d)
+		decl, nl, newOffset, floating := buildImportDecl(offset, d, lineOf)
 		newLines = append(newLines, nl...)
+		floatingComments = append(floatingComments, floating...)
 		astDecls = append(astDecls, decl)
 		offset = newOffset
 	}
-	return astDecls, newLines, offset
+	return astDecls, newLines, offset, floatingComments
 }
 
-func buildImportDecl(offset token.Pos, decl types.ImportDeclaration) (ast.Decl, []token.Pos, token.Pos) {
-	var newLines []token.Pos
+func buildImportDecl(offset token.Pos, decl types.ImportDeclaration, lineOf func(token.Pos) int) (ast.Decl, []token.Pos, token.Pos, []*ast.CommentGroup) {
+	var (
+		newLines         []token.Pos
+		floatingComments []*ast.CommentGroup
+	)
 	astDecl := &ast.GenDecl{
 		Tok: token.IMPORT,
 	}
 
 	// Prepare Doc comment for the import group.
-	var astCommentList []*ast.Comment
+	var (
+		astCommentList []*ast.Comment
+		docGaps        map[*ast.Comment]bool
+	)
 	for _, cg := range decl.LeadingComments {
 		astCommentList = append(astCommentList, copyCommentList(cg.List)...)
 	}
 	if decl.Doc != nil {
-		astCommentList = append(astCommentList, copyCommentList(decl.Doc.List)...)
+		docList := copyCommentList(decl.Doc.List)
+		docGaps = commentGaps(lineOf, decl.Doc.List, docList)
+		astCommentList = append(astCommentList, docList...)
 	}
 	for _, cg := range decl.DetachedComments {
 		astCommentList = append(astCommentList, copyCommentList(cg.List)...)
@@ -46,7 +64,7 @@ func buildImportDecl(offset token.Pos, decl types.ImportDeclaration) (ast.Decl,
 
 	// Place the doc comment at the current offset if it exists and calculate newlines.
 	if astDecl.Doc != nil {
-		newLines = buildCommentListNewlines(astDecl.Doc.List, newLines)
+		newLines = buildCommentListNewlinesWithGaps(astDecl.Doc.List, docGaps, newLines)
 
 		// Move offset to the next character after comment end.
 		offset = astDecl.Doc.End() + 1
@@ -61,8 +79,10 @@ func buildImportDecl(offset token.Pos, decl types.ImportDeclaration) (ast.Decl,
 	// Ensure declaration starts from a new line.
 	newLines = append(newLines, offset)
 
-	// Handle single import case (no parenthesis should be added).
-	if len(decl.ImportGroups) == 1 && len(decl.ImportGroups[0].Specs) == 1 {
+	// Handle single import case (no parenthesis should be added unless requested, or unless the group
+	// carries floating comments, which can only be printed inside the parenthesis).
+	if !decl.Parenthesized && len(decl.ImportGroups) == 1 && len(decl.ImportGroups[0].Specs) == 1 &&
+		len(decl.ImportGroups[0].LeadingComments) == 0 {
 		g := decl.ImportGroups[0]
 		s := g.Specs[0]
 		astSpec := copyImportSpec(s)
@@ -70,8 +90,10 @@ func buildImportDecl(offset token.Pos, decl types.ImportDeclaration) (ast.Decl,
 		// If the group has a doc comment but the spec doesn't, and the declaration doesn't have a doc,
 		// move the group's doc to the declaration's doc (so it appears before "import" keyword)
 		if astDecl.Doc == nil && astSpec.Doc == nil && g.Doc != nil {
-			astDecl.Doc = buildCombinedCommentGroup(offset, g.Doc.List)
-			newLines = buildCommentListNewlines(astDecl.Doc.List, newLines)
+			docList := copyCommentList(g.Doc.List)
+			docGaps := commentGaps(lineOf, g.Doc.List, docList)
+			astDecl.Doc = buildCombinedCommentGroup(offset, docList)
+			newLines = buildCommentListNewlinesWithGaps(astDecl.Doc.List, docGaps, newLines)
 			offset = astDecl.Doc.End() + 1
 			newLines = append(newLines, offset)
 
@@ -110,6 +132,18 @@ func buildImportDecl(offset token.Pos, decl types.ImportDeclaration) (ast.Decl,
 				offset++
 			}
 
+			// Place comments floating above the group, separated from it by a blank line.
+			for _, cg := range g.LeadingComments {
+				astComments := buildCombinedCommentGroup(offset, copyCommentList(cg.List))
+				newLines = buildCommentListNewlines(astComments.List, newLines)
+				floatingComments = append(floatingComments, astComments)
+				offset = astComments.End() + 1
+
+				// Assert newline at the end of the comment group, followed by a blank line.
+				newLines = append(newLines, offset, offset+1)
+				offset += 2
+			}
+
 			for specIdx, s := range g.Specs {
 				astSpec := copyImportSpec(s)
 				var astSpecDoc *ast.CommentGroup
@@ -133,7 +167,7 @@ func buildImportDecl(offset token.Pos, decl types.ImportDeclaration) (ast.Decl,
 		offset++
 	}
 
-	return astDecl, newLines, offset
+	return astDecl, newLines, offset, floatingComments
 }
 
 func buildImportSpec(offset token.Pos, astSpec *ast.ImportSpec, astSpecDoc *ast.CommentGroup, newLines []token.Pos) (token.Pos, []token.Pos) {
@@ -186,7 +220,17 @@ func buildCombinedCommentGroup(offset token.Pos, astCommentList []*ast.Comment)
 }
 
 func buildCommentListNewlines(comments []*ast.Comment, newLines []token.Pos) []token.Pos {
+	return buildCommentListNewlinesWithGaps(comments, nil, newLines)
+}
+
+// buildCommentListNewlinesWithGaps is buildCommentListNewlines that also inserts a blank line
+// before every comment present in gaps.
+func buildCommentListNewlinesWithGaps(comments []*ast.Comment, gaps map[*ast.Comment]bool, newLines []token.Pos) []token.Pos {
 	for _, c := range comments {
+		if gaps[c] {
+			newLines = append(newLines, c.Pos()-1)
+		}
+
 		// Ensure all comments start from new line.
 		// c.Pos() reports correct offset since we have already aligned comments earlier.
 		newLines = append(newLines, c.Pos())
@@ -199,6 +243,27 @@ func buildCommentListNewlines(comments []*ast.Comment, newLines []token.Pos) []t
 	return newLines
 }
 
+// commentGaps returns copies of comments that were separated from the preceding comment by
+// a blank line in the source, which happens when comment groups get merged.
+func commentGaps(lineOf func(token.Pos) int, original []*ast.Comment, copies []*ast.Comment) map[*ast.Comment]bool {
+	if lineOf == nil {
+		return nil
+	}
+
+	var gaps map[*ast.Comment]bool
+	for i := 1; i < len(original); i++ {
+		prevEnd, start := lineOf(original[i-1].End()), lineOf(original[i].Pos())
+		if prevEnd == 0 || start == 0 || start-prevEnd < 2 {
+			continue
+		}
+		if gaps == nil {
+			gaps = map[*ast.Comment]bool{}
+		}
+		gaps[copies[i]] = true
+	}
+	return gaps
+}
+
 func copyComment(c *ast.Comment) *ast.Comment {
 	if c == nil {
 		return nil
@@ -16,6 +16,9 @@ type (
 
 		Pos token.Pos
 		End token.Pos
+
+		// Disabled is set when the file is marked with the `//gofancyimports:off` directive.
+		Disabled bool
 	}
 
 	ImportDeclarationComments struct {
@@ -55,12 +58,27 @@ func ParseImportDeclarations(fset *token.FileSet, node *ast.File) (ImportDeclara
 		return ImportDeclarationRange{}, fmt.Errorf("found %d non import declarations ovelapping imports", len(nonImportDecls))
 	}
 
+	// The off directive applies to the whole file, it is only recognized in comments preceding
+	// the package clause or the first declaration.
+	disabled := false
+	for _, cg := range node.Comments {
+		if len(node.Decls) > 0 && cg.Pos() >= node.Decls[0].Pos() {
+			break
+		}
+		if types.HasDirective(cg, types.DirectiveOff) {
+			disabled = true
+			break
+		}
+	}
+
 	return ImportDeclarationRange{
 		Statements: importDecls,
 		Comments:   parseCommentsInRange(node.Comments, firstPos, lastPos),
 
 		Pos: firstPos,
 		End: lastPos,
+
+		Disabled: disabled,
 	}, nil
 
 }
@@ -94,28 +112,43 @@ func parseImportStatement(
 		lastGroupIndex := len(importGroups) - 1
 		currGroup := importGroups[lastGroupIndex]
 
+		var specDetachedComments []*ast.CommentGroup
 		for _, cg := range nodeComments {
 			// Find floating comments that start after previous spec and end before this one.
 			if prevSpecRange.End != token.NoPos && cg.Pos() > prevSpecRange.End && cg.Pos() < specRange.Pos {
-				currDecl.DetachedComments = append(currDecl.DetachedComments, cg)
+				specDetachedComments = append(specDetachedComments, cg)
 			}
 
 			// Catch comments after Open Paren that are not attached to any specs.
 			if specIdx == 0 && importDecl.Lparen != token.NoPos {
 				if cg.Pos() > importDecl.Lparen && cg.Pos() < specRange.Pos {
-					currDecl.DetachedComments = append(currDecl.DetachedComments, cg)
+					specDetachedComments = append(specDetachedComments, cg)
 				}
 			}
 		}
+		currDecl.DetachedComments = append(currDecl.DetachedComments, specDetachedComments...)
 
 		if importSpec, ok := spec.(*ast.ImportSpec); ok {
 			ispecCopy := copyImportSpec(importSpec)
 			if shouldRecordComment {
 				currGroup.Doc = ispecCopy.Doc
+				currGroup.NoSort = types.HasDirective(currGroup.Doc, types.DirectiveNoSort)
 				ispecCopy.Doc = nil
 			}
 			currGroup.Specs = append(currGroup.Specs, ispecCopy)
 
+			// Record the spec following detached comments for comment placement policies.
+			for _, cg := range specDetachedComments {
+				anchorComment(&currDecl, cg, ispecCopy)
+			}
+
+			if name, ok := types.DirectiveValue(ispecCopy.Comment, types.DirectiveGroup); ok {
+				if currGroup.SpecGroups == nil {
+					currGroup.SpecGroups = map[*ast.ImportSpec]string{}
+				}
+				currGroup.SpecGroups[ispecCopy] = name
+			}
+
 			// Since we took off the doc comment, adjust the start of the spec range to after comment.
 			prevSpecRange = astutils.ASTNodeRangeWithComments(importSpec)
 			prevSpecRange.Pos = importSpec.Pos()
@@ -131,6 +164,9 @@ func parseImportStatement(
 		// Find leading comments that start after last declaration.
 		if nodeCommentOffset != token.NoPos && cg.Pos() > nodeCommentOffset && cg.Pos() < importDeclRange.Pos {
 			currDecl.LeadingComments = append(currDecl.LeadingComments, cg)
+			if len(importGroups) > 0 && len(importGroups[0].Specs) > 0 {
+				anchorComment(&currDecl, cg, importGroups[0].Specs[0])
+			}
 		}
 
 		if prevSpecRange.End != token.NoPos && importDecl.Rparen != token.NoPos {
@@ -140,9 +176,21 @@ func parseImportStatement(
 			}
 		}
 	}
+
+	currDecl.Keep = types.HasDirective(currDecl.Doc, types.DirectiveKeep)
+	for _, cg := range currDecl.DetachedComments {
+		currDecl.Keep = currDecl.Keep || types.HasDirective(cg, types.DirectiveKeep)
+	}
 	return currDecl, astutils.ASTNodeRangeWithComments(importDecl)
 }
 
+func anchorComment(decl *types.ImportDeclaration, cg *ast.CommentGroup, spec *ast.ImportSpec) {
+	if decl.CommentAnchors == nil {
+		decl.CommentAnchors = map[*ast.CommentGroup]*ast.ImportSpec{}
+	}
+	decl.CommentAnchors[cg] = spec
+}
+
 func parseCommentsInRange(comments []*ast.CommentGroup, startPos, endPos token.Pos) ImportDeclarationComments {
 	result := ImportDeclarationComments{}
 
@@ -5,6 +5,7 @@ import (
 	"go/ast"
 	"go/parser"
 	"go/token"
+	gotypes "go/types"
 	"os"
 	"path/filepath"
 	"strings"
@@ -14,6 +15,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/NonLogicalDev/gofancyimports"
+	"github.com/NonLogicalDev/gofancyimports/internal/modindex"
 	"github.com/NonLogicalDev/gofancyimports/pkg/organizer/autogroup"
 	"github.com/NonLogicalDev/gofancyimports/pkg/types"
 )
@@ -109,69 +111,337 @@ func TestTestset00(t *testing.T) {
 }
 
 func TestTestset01(t *testing.T) {
-	runTestSetFromFolder(t, "testdata/testset_exhaustive", ".go.in", ".go.out", func(testname TestName) types.ImportTransform {
-		switch {
-		case testname == "noimports_hard_custom_transform":
-			return func(decls []types.ImportDeclaration) []types.ImportDeclaration {
-				return []types.ImportDeclaration{
-					{
-						Doc: &ast.CommentGroup{
-							List: []*ast.Comment{
-								{Text: "// extra"},
-							},
+	runTestSetFromFolderWithOptions(t, "testdata/testset_exhaustive", ".go.in", ".go.out", func(testname TestName) []gofancyimports.Option {
+		if strings.HasPrefix(testname, "doc_merge_transform") {
+			return []gofancyimports.Option{gofancyimports.WithFileTransform(func(fset *token.FileSet, file *ast.File) types.ImportTransform {
+				return func(decls []types.ImportDeclaration) []types.ImportDeclaration {
+					lineOf := func(pos token.Pos) int { return fset.Position(pos).Line }
+
+					merged := types.MergeDeclarations(decls)
+					merged.Doc = types.DedupDocComments(types.MergeDeclarationDocs(decls), lineOf)
+					return []types.ImportDeclaration{merged}
+				}
+			})}
+		}
+		return []gofancyimports.Option{gofancyimports.WithTransform(exhaustiveTransform(testname))}
+	})
+}
+
+func exhaustiveTransform(testname TestName) types.ImportTransform {
+	switch {
+	case strings.HasPrefix(testname, "noimports_"):
+		return func(decls []types.ImportDeclaration) []types.ImportDeclaration {
+			return []types.ImportDeclaration{
+				{
+					Doc: &ast.CommentGroup{
+						List: []*ast.Comment{
+							{Text: "// extra"},
 						},
-						ImportGroups: []types.ImportGroup{
-							{
-								Doc: &ast.CommentGroup{
-									List: []*ast.Comment{
-										{Text: "// first import"},
-									},
+					},
+					ImportGroups: []types.ImportGroup{
+						{
+							Doc: &ast.CommentGroup{
+								List: []*ast.Comment{
+									{Text: "// first import"},
 								},
-								Specs: []*ast.ImportSpec{
-									{
-										Path: &ast.BasicLit{
-											Kind:  token.STRING,
-											Value: `"fmt"`,
-										},
+							},
+							Specs: []*ast.ImportSpec{
+								{
+									Path: &ast.BasicLit{
+										Kind:  token.STRING,
+										Value: `"fmt"`,
 									},
-									{
-										Path: &ast.BasicLit{
-											Kind:  token.STRING,
-											Value: `"sync"`,
-										},
+								},
+								{
+									Path: &ast.BasicLit{
+										Kind:  token.STRING,
+										Value: `"sync"`,
 									},
 								},
 							},
+						},
 
-							{
-								Doc: &ast.CommentGroup{
-									List: []*ast.Comment{
-										{Text: "// second import"},
-									},
+						{
+							Doc: &ast.CommentGroup{
+								List: []*ast.Comment{
+									{Text: "// second import"},
 								},
-								Specs: []*ast.ImportSpec{
-									{
-										Path: &ast.BasicLit{
-											Kind:  token.STRING,
-											Value: `"net/http"`,
-										},
+							},
+							Specs: []*ast.ImportSpec{
+								{
+									Path: &ast.BasicLit{
+										Kind:  token.STRING,
+										Value: `"net/http"`,
 									},
 								},
 							},
 						},
 					},
-				}
+				},
 			}
-		case strings.HasPrefix(testname, "bugreport_3"):
-			return autogroup.New(
-				autogroup.WithSideEffectGroupEnabled(true),
-			)
 		}
+	case strings.HasPrefix(testname, "doc_merge_first"):
+		return func(decls []types.ImportDeclaration) []types.ImportDeclaration {
+			return []types.ImportDeclaration{types.MergeDeclarations(decls)}
+		}
+	case strings.HasPrefix(testname, "alias_group"):
+		return autogroup.New(
+			autogroup.WithAliasGroup(true),
+			autogroup.WithSpecFixups(autogroup.FixupDefaultImportAlias(map[string]*gotypes.Package{
+				"github.com/go-redis/redis/v8": gotypes.NewPackage("github.com/go-redis/redis/v8", "redis"),
+			})),
+		)
+	case strings.HasPrefix(testname, "blank_comments"):
+		return autogroup.New(
+			autogroup.WithSpecFixups(autogroup.FixupBlankImportComments(autogroup.DefaultBlankImportComments)),
+		)
+	case strings.HasPrefix(testname, "group_depth_org"):
+		return autogroup.New(
+			autogroup.WithLocalPrefixGroup([]string{"github.com/acme"}),
+			autogroup.WithGroupByPathDepth(autogroup.PathDepthOrg),
+		)
+	case strings.HasPrefix(testname, "group_depth_host"):
+		return autogroup.New(
+			autogroup.WithLocalPrefixGroup([]string{"github.com/acme"}),
+			autogroup.WithGroupByPathDepth(autogroup.PathDepthHost),
+			autogroup.WithGroupByPathMinSize(1),
+		)
+	case strings.HasPrefix(testname, "x_group_prefixes"):
+		return autogroup.New(
+			autogroup.WithExtendedStdlibGroup(true),
+			autogroup.WithExtendedStdlibPrefixes(append(autogroup.DefaultExtendedStdlibPrefixes, "google.golang.org/protobuf/")),
+		)
+	case strings.HasPrefix(testname, "x_group"):
+		return autogroup.New(
+			autogroup.WithExtendedStdlibGroup(true),
+		)
+	case strings.HasPrefix(testname, "sticky_preserve_decl"):
+		return autogroup.New(
+			autogroup.WithStickyGroupSorting(false),
+			autogroup.WithSpecFixups(autogroup.FixupEmbedPackage),
+		)
+	case strings.HasPrefix(testname, "sticky_preserve"):
+		return autogroup.New(
+			autogroup.WithStickyGroupSorting(false),
+		)
+	case strings.HasPrefix(testname, "sticky_before"):
+		return autogroup.New(
+			autogroup.WithStickyGroupPlacement(autogroup.StickyGroupsBefore),
+		)
+	case strings.HasPrefix(testname, "sticky_interleaved"):
+		return autogroup.New(
+			autogroup.WithStickyGroupPlacement(autogroup.StickyGroupsInterleaved),
+		)
+	case strings.HasPrefix(testname, "layout_merge_all"):
+		return autogroup.New(
+			autogroup.WithDeclarationLayout(autogroup.MergeAll),
+		)
+	case strings.HasPrefix(testname, "layout_one_per_group"):
+		return autogroup.New(
+			autogroup.WithDeclarationLayout(autogroup.OnePerGroup),
+		)
+	case strings.HasPrefix(testname, "layout_single_parens"):
+		return autogroup.New(
+			autogroup.WithSingleImportStyle(autogroup.SingleImportParenthesized),
+		)
+	case strings.HasPrefix(testname, "comments_inside"):
+		return autogroup.New(
+			autogroup.WithCommentPlacement(autogroup.CommentsKeepInside),
+		)
+	case strings.HasPrefix(testname, "comments_attach"):
+		return autogroup.New(
+			autogroup.WithCommentPlacement(autogroup.CommentsAttach),
+		)
+	case strings.HasPrefix(testname, "internal_group_module"):
+		return autogroup.New(
+			autogroup.WithInternalGroup(true),
+			autogroup.WithInternalGroupModule("github.com/acme/svc"),
+		)
+	case strings.HasPrefix(testname, "internal_group"):
+		return autogroup.New(
+			autogroup.WithInternalGroup(true),
+		)
+	case strings.HasPrefix(testname, "dot_group_ordered"):
+		return autogroup.New(
+			autogroup.WithDotImportGroup(true),
+			autogroup.WithGroupOrder(autogroup.GroupDot, autogroup.GroupStdlib),
+		)
+	case strings.HasPrefix(testname, "dot_group"):
+		return autogroup.New(
+			autogroup.WithDotImportGroup(true),
+			autogroup.WithSideEffectGroupEnabled(true),
+		)
+	case strings.HasPrefix(testname, "bugreport_3"):
+		return autogroup.New(
+			autogroup.WithSideEffectGroupEnabled(true),
+		)
+	}
 
-		return autogroup.New()
+	return autogroup.New()
+}
+
+func TestTestset02(t *testing.T) {
+	runFileTestSetFromFolder(t, "testdata/testset_file", ".go.in", ".go.out", func(testname TestName) []autogroup.Option {
+		switch {
+		case strings.HasPrefix(testname, "missing_stdlib_go1_20"):
+			return []autogroup.Option{
+				autogroup.WithMissingImportResolvers(autogroup.ResolveStdlibImports("1.20")),
+			}
+		case strings.HasPrefix(testname, "directives"):
+			return []autogroup.Option{
+				autogroup.WithDirectiveImports(true),
+			}
+		case strings.HasPrefix(testname, "doc_dedup_paragraphs"):
+			return []autogroup.Option{
+				autogroup.WithDocCommentDedup(true),
+			}
+		case strings.HasPrefix(testname, "doc_dedup"):
+			return []autogroup.Option{
+				autogroup.WithDeclarationLayout(autogroup.MergeAll),
+				autogroup.WithDocCommentDedup(true),
+			}
+		case strings.HasPrefix(testname, "comments_inside"):
+			return []autogroup.Option{
+				autogroup.WithCommentPlacement(autogroup.CommentsKeepInside),
+			}
+		case strings.HasPrefix(testname, "missing_module"):
+			idx, err := modindex.LoadCached("testdata/modules/go.mod")
+			require.NoError(t, err)
+
+			siblingImports := []*ast.ImportSpec{{
+				Name: &ast.Ident{Name: "pb"},
+				Path: &ast.BasicLit{Kind: token.STRING, Value: `"example.com/app/proto/gen"`},
+			}}
+			return []autogroup.Option{
+				autogroup.WithLocalPrefixGroup([]string{"example.com/app"}),
+				autogroup.WithMissingImportResolvers(
+					autogroup.ResolveFromImports(siblingImports),
+					autogroup.ResolveStdlibImports(""),
+					idx.Resolver("testdata/modules/cmd", nil),
+				),
+			}
+		case strings.HasPrefix(testname, "undertest_trailing"):
+			return []autogroup.Option{
+				autogroup.WithPackagePath("github.com/acme/widget/v2"),
+				autogroup.WithPackageUnderTestGroup(autogroup.PackageUnderTestTrailing),
+			}
+		case strings.HasPrefix(testname, "undertest"):
+			return []autogroup.Option{
+				autogroup.WithPackageUnderTestGroup(autogroup.PackageUnderTestLeading),
+				autogroup.WithTestHelperGroup(true),
+			}
+		case strings.HasPrefix(testname, "alias_unify"):
+			return []autogroup.Option{
+				autogroup.WithImportAliases(map[string]string{
+					"github.com/sirupsen/logrus": "logrus",
+					"github.com/acme/api/proto":  "acmepb",
+				}),
+			}
+		case strings.HasPrefix(testname, "alias_set"):
+			return []autogroup.Option{
+				autogroup.WithImportAliases(map[string]string{
+					"k8s.io/api/core/v1":        "corev1",
+					"github.com/acme/api/proto": "acmepb",
+				}),
+			}
+		case strings.HasPrefix(testname, "migrate_path_alias"):
+			return []autogroup.Option{
+				autogroup.WithImportPathMigrations(testPathMigrations, false),
+			}
+		case strings.HasPrefix(testname, "migrate_path_selectors"):
+			return []autogroup.Option{
+				autogroup.WithImportPathMigrations(testPathMigrations, true),
+			}
+		case strings.HasPrefix(testname, "migrate_stdlib_go1_15"):
+			return []autogroup.Option{
+				autogroup.WithSymbolMigrations("1.15", autogroup.DefaultStdlibMigrations),
+			}
+		case strings.HasPrefix(testname, "migrate_stdlib"):
+			return []autogroup.Option{
+				autogroup.WithSymbolMigrations("", autogroup.DefaultStdlibMigrations),
+			}
+		case strings.HasPrefix(testname, "collision_initial"):
+			return []autogroup.Option{
+				autogroup.WithCollisionAliases(autogroup.AliasParentInitial),
+			}
+		case strings.HasPrefix(testname, "collision_untyped"):
+			return []autogroup.Option{
+				autogroup.WithCollisionAliases(autogroup.AliasParentDir),
+			}
+		case strings.HasPrefix(testname, "collision_module"):
+			idx, err := modindex.LoadCached("testdata/modules/go.mod")
+			require.NoError(t, err)
+
+			return []autogroup.Option{
+				autogroup.WithCollisionAliases(autogroup.AliasParentDir),
+				autogroup.WithPackageTypes(idx.PackageTypes([]string{
+					"example.com/app/legacy/widget",
+					"example.com/app/pkg/widget",
+				})),
+			}
+		case strings.HasPrefix(testname, "collision_parent"):
+			return []autogroup.Option{
+				autogroup.WithCollisionAliases(autogroup.AliasParentDir),
+				autogroup.WithPackageTypes(map[string]*gotypes.Package{
+					"k8s.io/api/apps/v1": newTestPackage("k8s.io/api/apps/v1", "v1", "Deployment"),
+					"k8s.io/api/core/v1": newTestPackage("k8s.io/api/core/v1", "v1", "Pod"),
+				}),
+			}
+		case strings.HasPrefix(testname, "missing_stdlib"):
+			return []autogroup.Option{
+				autogroup.WithPackageDeclarations([]string{"declaredElsewhere"}),
+				autogroup.WithMissingImportResolvers(autogroup.ResolveStdlibImports("")),
+			}
+		}
+
+		return nil
+	})
+}
+
+func TestTestset03(t *testing.T) {
+	runTestSetFromFolderWithOptions(t, "testdata/testset_alias", ".go.in", ".go.out", func(testname TestName) []gofancyimports.Option {
+		return []gofancyimports.Option{gofancyimports.WithFileTransform(func(fset *token.FileSet, file *ast.File) types.ImportTransform {
+			return autogroup.NewAliasTransform(
+				autogroup.WithFile(fset, file),
+				autogroup.WithImportAliases(map[string]string{
+					"github.com/sirupsen/logrus": "logrus",
+					"github.com/acme/api/proto":  "acmepb",
+				}),
+			)
+		})}
+	})
+}
+
+func TestTestset04(t *testing.T) {
+	runPackageTestSetFromFolder(t, "testdata/testset_package", ".go.in", ".go.out", func(testname TestName) []autogroup.Option {
+		switch {
+		case strings.HasPrefix(testname, "consistent_aliases"):
+			return []autogroup.Option{
+				autogroup.WithConsistentAliases(true),
+			}
+		default:
+			return nil
+		}
 	})
 }
 
+// testPathMigrations moves a package to a new major version under a new name and replaces a
+// third party package with the standard library.
+var testPathMigrations = map[string]string{
+	"github.com/old/lib":    "github.com/acme/newlib/v2",
+	"github.com/pkg/errors": "errors",
+}
+
+// newTestPackage returns complete type information of a package exporting the types.
+func newTestPackage(path string, name string, typeNames ...string) *gotypes.Package {
+	pkg := gotypes.NewPackage(path, name)
+	for _, typeName := range typeNames {
+		pkg.Scope().Insert(gotypes.NewTypeName(token.NoPos, pkg, typeName, nil))
+	}
+	pkg.MarkComplete()
+	return pkg
+}
+
 type (
 	TestName   = string
 	TestConfig struct {
@@ -192,6 +462,21 @@ const (
 var TestRerunCount = 3
 
 func runTestSetFromFolder(t *testing.T, testsetpath string, suffixin string, suffixout string, transformpicker func(TestName) types.ImportTransform) {
+	runTestSetFromFolderWithOptions(t, testsetpath, suffixin, suffixout, func(testname TestName) []gofancyimports.Option {
+		return []gofancyimports.Option{gofancyimports.WithTransform(transformpicker(testname))}
+	})
+}
+
+func runFileTestSetFromFolder(t *testing.T, testsetpath string, suffixin string, suffixout string, optionpicker func(TestName) []autogroup.Option) {
+	runTestSetFromFolderWithOptions(t, testsetpath, suffixin, suffixout, func(testname TestName) []gofancyimports.Option {
+		opts := optionpicker(testname)
+		return []gofancyimports.Option{gofancyimports.WithFileTransform(func(fset *token.FileSet, file *ast.File) types.ImportTransform {
+			return autogroup.New(append([]autogroup.Option{autogroup.WithFile(fset, file)}, opts...)...)
+		})}
+	})
+}
+
+func runTestSetFromFolderWithOptions(t *testing.T, testsetpath string, suffixin string, suffixout string, optionpicker func(TestName) []gofancyimports.Option) {
 	table := make(map[TestName]TestConfig)
 
 	direntries, err := os.ReadDir(testsetpath)
@@ -237,19 +522,19 @@ func runTestSetFromFolder(t *testing.T, testsetpath string, suffixin string, suf
 
 	for _, tt := range table {
 		t.Run(tt.name, func(t *testing.T) {
-			transform := transformpicker(tt.name)
+			opts := optionpicker(tt.name)
 
 			actualSrc := tt.srcIn
 			for i := 0; i < TestRerunCount; i++ {
 				fset := token.NewFileSet()
-				file, err := parser.ParseFile(fset, "input.go", actualSrc, parser.ParseComments)
+				file, err := parser.ParseFile(fset, tt.name+".go", actualSrc, parser.ParseComments)
 				require.NoError(t, err)
 
-				edits, err := gofancyimports.RewriteImportsAST(fset, file, []byte(actualSrc), gofancyimports.WithTransform(transform))
+				edits, err := gofancyimports.RewriteImportsAST(fset, file, []byte(actualSrc), opts...)
 				require.NoError(t, err)
 
 				if len(edits) > 0 {
-					actualSrc = string(gofancyimports.ApplyTextEdit(fset, file, []byte(actualSrc), edits[0]))
+					actualSrc = string(gofancyimports.ApplyTextEdits(fset, file, []byte(actualSrc), edits))
 				}
 				if !assert.Equal(t, tt.srcOut, actualSrc) {
 					t.Logf("actual src:\n%v", actualSrc)
@@ -259,3 +544,51 @@ func runTestSetFromFolder(t *testing.T, testsetpath string, suffixin string, suf
 		})
 	}
 }
+
+// runPackageTestSetFromFolder runs every directory of the test set as a package, rewriting all
+// input files of the directory at once and comparing every file with its output.
+func runPackageTestSetFromFolder(t *testing.T, testsetpath string, suffixin string, suffixout string, optionpicker func(TestName) []autogroup.Option) {
+	direntries, err := os.ReadDir(testsetpath)
+	require.NoError(t, err)
+
+	for _, de := range direntries {
+		if !de.IsDir() {
+			continue
+		}
+		name := de.Name()
+		t.Run(name, func(t *testing.T) {
+			inputs, err := filepath.Glob(filepath.Join(testsetpath, name, "*"+suffixin))
+			require.NoError(t, err)
+			require.NotEmpty(t, inputs)
+
+			var (
+				filenames []string
+				srcs      [][]byte
+				expected  []string
+			)
+			for _, input := range inputs {
+				src, err := os.ReadFile(input)
+				require.NoError(t, err)
+				out, err := os.ReadFile(strings.TrimSuffix(input, suffixin) + suffixout)
+				require.NoError(t, err)
+
+				filenames = append(filenames, filepath.Base(strings.TrimSuffix(input, suffixin))+".go")
+				srcs = append(srcs, src)
+				expected = append(expected, string(out))
+			}
+
+			transform := autogroup.NewPackage(nil, optionpicker(name)...)
+			for i := 0; i < TestRerunCount; i++ {
+				srcs, err = gofancyimports.RewritePackageImportsSource(filenames, srcs, transform)
+				require.NoError(t, err)
+
+				for fileIdx, src := range srcs {
+					if !assert.Equal(t, expected[fileIdx], string(src), filenames[fileIdx]) {
+						t.Logf("actual src of %s:\n%v", filenames[fileIdx], string(src))
+						t.FailNow()
+					}
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,373 @@
+package astutils
+
+import (
+	"go/ast"
+	"go/token"
+	"sort"
+)
+
+// UnresolvedSelectors returns qualified identifier references (`X.Sel`) whose qualifier `X`
+// is not declared anywhere in scope within the file, mapped to the sorted list of selected
+// names. Imported package names are not treated as declarations, so references to existing
+// imports are reported as well and are expected to be filtered by the caller.
+//
+// The scope resolution is purely syntactic, it does not require type information and works
+// regardless of whether the file was parsed with object resolution enabled.
+func UnresolvedSelectors(file *ast.File) map[string][]string {
+	refs := map[string]map[string]struct{}{}
+	for _, sel := range PackageSelectors(file) {
+		name := sel.X.(*ast.Ident).Name
+		if refs[name] == nil {
+			refs[name] = map[string]struct{}{}
+		}
+		refs[name][sel.Sel.Name] = struct{}{}
+	}
+
+	result := make(map[string][]string, len(refs))
+	for name, selectors := range refs {
+		for sel := range selectors {
+			result[name] = append(result[name], sel)
+		}
+		sort.Strings(result[name])
+	}
+	return result
+}
+
+// PackageSelectors returns qualified identifier references (`X.Sel`) whose qualifier `X` is
+// an identifier not declared anywhere in scope within the file, i.e. references to imported
+// (or missing) packages, in the order of the source.
+func PackageSelectors(file *ast.File) []*ast.SelectorExpr {
+	return resolveScopes(file).refs
+}
+
+// LocalNames returns names declared in local scopes within the file (parameters, results,
+// variables, constants and types declared inside functions), sorted. Imports available under
+// any of these names are shadowed in some scope of the file.
+func LocalNames(file *ast.File) []string {
+	r := resolveScopes(file)
+	names := make([]string, 0, len(r.locals))
+	for name := range r.locals {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func resolveScopes(file *ast.File) *scopeResolver {
+	r := &scopeResolver{locals: map[string]struct{}{}}
+
+	r.push()
+	for _, name := range DeclaredNames(file) {
+		r.declare(name)
+	}
+	for _, decl := range file.Decls {
+		r.walk(decl)
+	}
+	r.pop()
+
+	return r
+}
+
+// DeclaredNames returns names of all package level declarations in the file,
+// excluding methods and imports.
+func DeclaredNames(file *ast.File) []string {
+	var names []string
+	for _, decl := range file.Decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			if decl.Recv == nil {
+				names = append(names, decl.Name.Name)
+			}
+		case *ast.GenDecl:
+			for _, spec := range decl.Specs {
+				switch spec := spec.(type) {
+				case *ast.TypeSpec:
+					names = append(names, spec.Name.Name)
+				case *ast.ValueSpec:
+					for _, id := range spec.Names {
+						names = append(names, id.Name)
+					}
+				}
+			}
+		}
+	}
+	return names
+}
+
+type scopeResolver struct {
+	scopes []map[string]struct{}
+	refs   []*ast.SelectorExpr
+	locals map[string]struct{}
+}
+
+func (r *scopeResolver) push() {
+	r.scopes = append(r.scopes, map[string]struct{}{})
+}
+
+func (r *scopeResolver) pop() {
+	r.scopes = r.scopes[:len(r.scopes)-1]
+}
+
+func (r *scopeResolver) declare(name string) {
+	if name == "_" || name == "" {
+		return
+	}
+	r.scopes[len(r.scopes)-1][name] = struct{}{}
+	if len(r.scopes) > 1 {
+		r.locals[name] = struct{}{}
+	}
+}
+
+func (r *scopeResolver) declareIdents(ids []*ast.Ident) {
+	for _, id := range ids {
+		r.declare(id.Name)
+	}
+}
+
+func (r *scopeResolver) declareFields(fields *ast.FieldList) {
+	if fields == nil {
+		return
+	}
+	for _, f := range fields.List {
+		r.declareIdents(f.Names)
+	}
+}
+
+func (r *scopeResolver) isDeclared(name string) bool {
+	for i := len(r.scopes) - 1; i >= 0; i-- {
+		if _, ok := r.scopes[i][name]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// walkFieldTypes walks only the types of the fields, names of fields are not references.
+func (r *scopeResolver) walkFieldTypes(fields *ast.FieldList) {
+	if fields == nil {
+		return
+	}
+	for _, f := range fields.List {
+		r.walk(f.Type)
+	}
+}
+
+// walkChildren walks direct children of the node.
+func (r *scopeResolver) walkChildren(node ast.Node) {
+	ast.Inspect(node, func(n ast.Node) bool {
+		if n == node {
+			return true
+		}
+		if n != nil {
+			r.walk(n)
+		}
+		return false
+	})
+}
+
+func (r *scopeResolver) walkList(nodes []ast.Stmt) {
+	for _, n := range nodes {
+		r.walk(n)
+	}
+}
+
+func (r *scopeResolver) walkFunc(typeParams *ast.FieldList, recv *ast.FieldList, fn *ast.FuncType, body *ast.BlockStmt) {
+	r.push()
+	defer r.pop()
+
+	// Type parameters are visible in the signature, parameters are only visible in the body.
+	r.declareFields(typeParams)
+	r.walkFieldTypes(typeParams)
+	r.walkFieldTypes(recv)
+	r.walkFieldTypes(fn.Params)
+	r.walkFieldTypes(fn.Results)
+
+	if body == nil {
+		return
+	}
+	r.declareFields(recv)
+	r.declareFields(fn.Params)
+	r.declareFields(fn.Results)
+	r.walk(body)
+}
+
+func (r *scopeResolver) walk(node ast.Node) {
+	switch n := node.(type) {
+	case nil:
+		return
+
+	case *ast.SelectorExpr:
+		if x, ok := n.X.(*ast.Ident); ok {
+			if !r.isDeclared(x.Name) {
+				r.refs = append(r.refs, n)
+			}
+			return
+		}
+		r.walk(n.X)
+
+	case *ast.FuncDecl:
+		var recvTypeParams *ast.FieldList
+		if n.Recv != nil && len(n.Recv.List) > 0 {
+			recvTypeParams = receiverTypeParams(n.Recv.List[0].Type)
+		}
+		if recvTypeParams == nil {
+			recvTypeParams = n.Type.TypeParams
+		}
+		r.walkFunc(recvTypeParams, n.Recv, n.Type, n.Body)
+
+	case *ast.FuncLit:
+		r.walkFunc(nil, nil, n.Type, n.Body)
+
+	case *ast.GenDecl:
+		for _, spec := range n.Specs {
+			r.walk(spec)
+		}
+
+	case *ast.ImportSpec:
+		return
+
+	case *ast.ValueSpec:
+		r.walk(n.Type)
+		for _, v := range n.Values {
+			r.walk(v)
+		}
+		r.declareIdents(n.Names)
+
+	case *ast.TypeSpec:
+		r.declare(n.Name.Name)
+		r.push()
+		r.declareFields(n.TypeParams)
+		r.walkFieldTypes(n.TypeParams)
+		r.walk(n.Type)
+		r.pop()
+
+	case *ast.StructType:
+		r.walkFieldTypes(n.Fields)
+
+	case *ast.InterfaceType:
+		r.walkFieldTypes(n.Methods)
+
+	case *ast.FuncType:
+		r.push()
+		r.declareFields(n.TypeParams)
+		r.walkFieldTypes(n.TypeParams)
+		r.walkFieldTypes(n.Params)
+		r.walkFieldTypes(n.Results)
+		r.pop()
+
+	case *ast.BlockStmt:
+		r.push()
+		r.walkList(n.List)
+		r.pop()
+
+	case *ast.AssignStmt:
+		for _, v := range n.Rhs {
+			r.walk(v)
+		}
+		if n.Tok == token.DEFINE {
+			for _, v := range n.Lhs {
+				if id, ok := v.(*ast.Ident); ok {
+					r.declare(id.Name)
+				}
+			}
+		} else {
+			for _, v := range n.Lhs {
+				r.walk(v)
+			}
+		}
+
+	case *ast.IfStmt:
+		r.push()
+		r.walk(n.Init)
+		r.walk(n.Cond)
+		r.walk(n.Body)
+		r.walk(n.Else)
+		r.pop()
+
+	case *ast.ForStmt:
+		r.push()
+		r.walk(n.Init)
+		r.walk(n.Cond)
+		r.walk(n.Post)
+		r.walk(n.Body)
+		r.pop()
+
+	case *ast.RangeStmt:
+		r.walk(n.X)
+		r.push()
+		if n.Tok == token.DEFINE {
+			for _, v := range []ast.Expr{n.Key, n.Value} {
+				if id, ok := v.(*ast.Ident); ok {
+					r.declare(id.Name)
+				}
+			}
+		} else {
+			r.walk(n.Key)
+			r.walk(n.Value)
+		}
+		r.walk(n.Body)
+		r.pop()
+
+	case *ast.SwitchStmt:
+		r.push()
+		r.walk(n.Init)
+		r.walk(n.Tag)
+		r.walk(n.Body)
+		r.pop()
+
+	case *ast.TypeSwitchStmt:
+		r.push()
+		r.walk(n.Init)
+		r.walk(n.Assign)
+		r.walk(n.Body)
+		r.pop()
+
+	case *ast.CaseClause:
+		for _, v := range n.List {
+			r.walk(v)
+		}
+		r.push()
+		r.walkList(n.Body)
+		r.pop()
+
+	case *ast.CommClause:
+		r.push()
+		r.walk(n.Comm)
+		r.walkList(n.Body)
+		r.pop()
+
+	case *ast.LabeledStmt:
+		r.walk(n.Stmt)
+
+	case *ast.BranchStmt:
+		return
+
+	default:
+		r.walkChildren(n)
+	}
+}
+
+// receiverTypeParams extracts type parameters declared by a generic receiver (e.g. `func (l *List[T]) ...`).
+func receiverTypeParams(expr ast.Expr) *ast.FieldList {
+	if star, ok := expr.(*ast.StarExpr); ok {
+		expr = star.X
+	}
+
+	var idents []ast.Expr
+	switch e := expr.(type) {
+	case *ast.IndexExpr:
+		idents = []ast.Expr{e.Index}
+	case *ast.IndexListExpr:
+		idents = e.Indices
+	default:
+		return nil
+	}
+
+	fields := &ast.FieldList{}
+	for _, idx := range idents {
+		if id, ok := idx.(*ast.Ident); ok {
+			fields.List = append(fields.List, &ast.Field{Names: []*ast.Ident{id}})
+		}
+	}
+	return fields
+}
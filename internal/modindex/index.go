@@ -0,0 +1,352 @@
+// Package modindex indexes packages that are visible to a module: packages of the module
+// itself and of the workspace it belongs to, vendored packages, and packages of the direct
+// requirements of the module found in the module cache.
+//
+// The index is purely syntactic, package names are read from package clauses and exported
+// names from top level declarations, no build constraints are evaluated.
+package modindex
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"golang.org/x/mod/module"
+
+	"github.com/NonLogicalDev/gofancyimports/internal/astutils"
+)
+
+type (
+	// Index is a collection of packages visible to a module, indexed by package name.
+	Index struct {
+		packages map[string][]*Package
+	}
+
+	// Package is a single indexed package.
+	Package struct {
+		ImportPath string
+		Name       string
+		Dir        string
+
+		// Local is true for packages of the main module and workspace modules.
+		Local bool
+
+		exportsOnce sync.Once
+		exports     map[string]bool
+	}
+
+	indexRoot struct {
+		dir        string
+		importPath string
+		local      bool
+	}
+)
+
+var (
+	_cacheMu sync.Mutex
+	_cache   = map[string]*Index{}
+)
+
+// LoadCached is same as [Load] but reuses indexes previously loaded for the same go.mod file.
+func LoadCached(goModPath string) (*Index, error) {
+	_cacheMu.Lock()
+	defer _cacheMu.Unlock()
+
+	if idx, ok := _cache[goModPath]; ok {
+		return idx, nil
+	}
+	idx, err := Load(goModPath)
+	if err != nil {
+		return nil, err
+	}
+	_cache[goModPath] = idx
+	return idx, nil
+}
+
+// Load builds an index of packages visible to the module defined by the go.mod file.
+func Load(goModPath string) (*Index, error) {
+	goModPath, err := filepath.Abs(goModPath)
+	if err != nil {
+		return nil, err
+	}
+	mod, err := readModFile(goModPath)
+	if err != nil {
+		return nil, err
+	}
+	modDir := filepath.Dir(goModPath)
+
+	var roots []indexRoot
+	if mod.Module != nil {
+		roots = append(roots, indexRoot{dir: modDir, importPath: mod.Module.Mod.Path, local: true})
+	}
+
+	// Modules of the workspace are local.
+	if goWorkPath, ok := FindGoWork(modDir); ok {
+		if work, err := readWorkFile(goWorkPath); err == nil {
+			for _, use := range work.Use {
+				useDir := filepath.Join(filepath.Dir(goWorkPath), filepath.FromSlash(use.Path))
+				if useDir == modDir {
+					continue
+				}
+				useMod, err := readModFile(filepath.Join(useDir, "go.mod"))
+				if err != nil || useMod.Module == nil {
+					continue
+				}
+				roots = append(roots, indexRoot{dir: useDir, importPath: useMod.Module.Mod.Path, local: true})
+			}
+		}
+	}
+
+	if info, err := os.Stat(filepath.Join(modDir, "vendor", "modules.txt")); err == nil && !info.IsDir() {
+		// In vendor mode vendored packages replace module cache.
+		roots = append(roots, indexRoot{dir: filepath.Join(modDir, "vendor")})
+	} else {
+		replacements := map[string]module.Version{}
+		for _, r := range mod.Replace {
+			replacements[r.Old.Path] = r.New
+		}
+		for _, req := range mod.Require {
+			if req.Indirect {
+				continue
+			}
+			dir, ok := moduleDir(modDir, req.Mod, replacements)
+			if !ok {
+				continue
+			}
+			roots = append(roots, indexRoot{dir: dir, importPath: req.Mod.Path})
+		}
+	}
+
+	idx := &Index{packages: map[string][]*Package{}}
+	for _, root := range roots {
+		idx.walk(root)
+	}
+	return idx, nil
+}
+
+// Resolver returns a resolver of package references for a file located in importerDir,
+// compatible with autogroup.ImportResolver. Import paths listed as preferred (typically the
+// ones already imported by other files in the package) take priority over other candidates.
+func (idx *Index) Resolver(importerDir string, preferred []string) func(pkgName string, symbols []string) (string, bool) {
+	importerDir, _ = filepath.Abs(importerDir)
+	preferredSet := make(map[string]bool, len(preferred))
+	for _, p := range preferred {
+		preferredSet[p] = true
+	}
+
+	return func(pkgName string, symbols []string) (string, bool) {
+		var candidates []*Package
+		for _, pkg := range idx.packages[pkgName] {
+			if pkg.Dir == importerDir || !pkg.visibleFrom(importerDir) || !pkg.exportsAll(symbols) {
+				continue
+			}
+			candidates = append(candidates, pkg)
+		}
+		if len(candidates) == 0 {
+			return "", false
+		}
+
+		sort.Slice(candidates, func(i, j int) bool {
+			a, b := candidates[i], candidates[j]
+			if preferredSet[a.ImportPath] != preferredSet[b.ImportPath] {
+				return preferredSet[a.ImportPath]
+			}
+			if a.Local != b.Local {
+				return a.Local
+			}
+			if da, db := strings.Count(a.ImportPath, "/"), strings.Count(b.ImportPath, "/"); da != db {
+				return da < db
+			}
+			return a.ImportPath < b.ImportPath
+		})
+		return candidates[0].ImportPath, true
+	}
+}
+
+// PackageTypes returns type information of the indexed packages with the provided import
+// paths, reduced to package names and exported names of top level declarations. Objects of
+// the packages have no meaningful types, they are only good for resolving names.
+func (idx *Index) PackageTypes(importPaths []string) map[string]*types.Package {
+	wanted := make(map[string]bool, len(importPaths))
+	for _, importPath := range importPaths {
+		wanted[importPath] = true
+	}
+
+	pkgTypes := map[string]*types.Package{}
+	for _, pkgs := range idx.packages {
+		for _, pkg := range pkgs {
+			if !wanted[pkg.ImportPath] || pkgTypes[pkg.ImportPath] != nil {
+				continue
+			}
+			pkg.exportsOnce.Do(pkg.loadExports)
+
+			typesPkg := types.NewPackage(pkg.ImportPath, pkg.Name)
+			for name := range pkg.exports {
+				typesPkg.Scope().Insert(types.NewVar(token.NoPos, typesPkg, name, types.Typ[types.Invalid]))
+			}
+			typesPkg.MarkComplete()
+			pkgTypes[pkg.ImportPath] = typesPkg
+		}
+	}
+	return pkgTypes
+}
+
+func (idx *Index) walk(root indexRoot) {
+	_ = filepath.WalkDir(root.dir, func(dir string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if dir != root.dir {
+			name := d.Name()
+			if strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") || name == "testdata" || name == "vendor" {
+				return filepath.SkipDir
+			}
+			// Nested modules are not part of the module being walked.
+			if root.importPath != "" {
+				if _, err := os.Stat(filepath.Join(dir, "go.mod")); err == nil {
+					return filepath.SkipDir
+				}
+			}
+		}
+
+		pkgName, ok := readPackageName(dir)
+		if !ok || pkgName == "main" {
+			return nil
+		}
+
+		rel, err := filepath.Rel(root.dir, dir)
+		if err != nil {
+			return nil
+		}
+		importPath := path.Join(root.importPath, filepath.ToSlash(rel))
+		if importPath == "." {
+			return nil
+		}
+
+		idx.packages[pkgName] = append(idx.packages[pkgName], &Package{
+			ImportPath: importPath,
+			Name:       pkgName,
+			Dir:        dir,
+			Local:      root.local,
+		})
+		return nil
+	})
+}
+
+// visibleFrom reports whether the package can be imported from importerDir according
+// to the visibility rule of `internal` packages.
+func (pkg *Package) visibleFrom(importerDir string) bool {
+	dir := filepath.ToSlash(pkg.Dir)
+	idx := strings.LastIndex(dir+"/", "/internal/")
+	if idx < 0 {
+		return true
+	}
+	parent := dir[:idx]
+	importer := filepath.ToSlash(importerDir)
+	return importer == parent || strings.HasPrefix(importer, parent+"/")
+}
+
+func (pkg *Package) exportsAll(symbols []string) bool {
+	pkg.exportsOnce.Do(pkg.loadExports)
+	for _, s := range symbols {
+		if !pkg.exports[s] {
+			return false
+		}
+	}
+	return true
+}
+
+func (pkg *Package) loadExports() {
+	pkg.exports = map[string]bool{}
+	for _, filename := range goFiles(pkg.Dir) {
+		file, err := parser.ParseFile(token.NewFileSet(), filename, nil, parser.SkipObjectResolution)
+		if err != nil || file.Name.Name != pkg.Name {
+			continue
+		}
+		for _, name := range astutils.DeclaredNames(file) {
+			if ast.IsExported(name) {
+				pkg.exports[name] = true
+			}
+		}
+	}
+}
+
+func readPackageName(dir string) (string, bool) {
+	for _, filename := range goFiles(dir) {
+		file, err := parser.ParseFile(token.NewFileSet(), filename, nil, parser.PackageClauseOnly)
+		if err != nil || strings.HasSuffix(file.Name.Name, "_test") || file.Name.Name == "documentation" {
+			continue
+		}
+		return file.Name.Name, true
+	}
+	return "", false
+}
+
+// goFiles returns non-test go files in the directory.
+func goFiles(dir string) []string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	var files []string
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		files = append(files, filepath.Join(dir, name))
+	}
+	return files
+}
+
+// moduleDir returns the directory holding sources of the required module version,
+// following replace directives.
+func moduleDir(modDir string, mod module.Version, replacements map[string]module.Version) (string, bool) {
+	if r, ok := replacements[mod.Path]; ok {
+		if modfileIsLocalPath(r.Path) {
+			return filepath.Join(modDir, filepath.FromSlash(r.Path)), true
+		}
+		mod = r
+	}
+
+	escapedPath, err := module.EscapePath(mod.Path)
+	if err != nil {
+		return "", false
+	}
+	escapedVersion, err := module.EscapeVersion(mod.Version)
+	if err != nil {
+		return "", false
+	}
+	dir := filepath.Join(moduleCacheDir(), filepath.FromSlash(escapedPath)+"@"+escapedVersion)
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return "", false
+	}
+	return dir, true
+}
+
+func modfileIsLocalPath(p string) bool {
+	return strings.HasPrefix(p, "./") || strings.HasPrefix(p, "../") || filepath.IsAbs(p)
+}
+
+func moduleCacheDir() string {
+	if dir := os.Getenv("GOMODCACHE"); dir != "" {
+		return dir
+	}
+	gopath := os.Getenv("GOPATH")
+	if gopath == "" {
+		home, _ := os.UserHomeDir()
+		gopath = filepath.Join(home, "go")
+	}
+	return filepath.Join(filepath.SplitList(gopath)[0], "pkg", "mod")
+}
@@ -0,0 +1,75 @@
+package modindex
+
+import (
+	"os"
+	"path/filepath"
+
+	"golang.org/x/mod/modfile"
+)
+
+// FindGoMod returns the path to the closest go.mod file in the provided directory or
+// any of its parents.
+func FindGoMod(dir string) (string, bool) {
+	return findUp(dir, "go.mod")
+}
+
+// FindGoWork returns the path to the closest go.work file in the provided directory or
+// any of its parents.
+func FindGoWork(dir string) (string, bool) {
+	return findUp(dir, "go.work")
+}
+
+// GoVersion returns the version from the `go` directive of the provided go.mod file,
+// or empty string if it can't be determined.
+func GoVersion(goModPath string) string {
+	mod, err := readModFile(goModPath)
+	if err != nil || mod.Go == nil {
+		return ""
+	}
+	return mod.Go.Version
+}
+
+// ModulePath returns the module path declared in the provided go.mod file,
+// or empty string if it can't be determined.
+func ModulePath(goModPath string) string {
+	mod, err := readModFile(goModPath)
+	if err != nil || mod.Module == nil {
+		return ""
+	}
+	return mod.Module.Mod.Path
+}
+
+func readModFile(goModPath string) (*modfile.File, error) {
+	data, err := os.ReadFile(goModPath)
+	if err != nil {
+		return nil, err
+	}
+	return modfile.ParseLax(goModPath, data, nil)
+}
+
+func readWorkFile(goWorkPath string) (*modfile.WorkFile, error) {
+	data, err := os.ReadFile(goWorkPath)
+	if err != nil {
+		return nil, err
+	}
+	return modfile.ParseWork(goWorkPath, data, nil)
+}
+
+func findUp(dir string, name string) (string, bool) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", false
+	}
+	for {
+		candidate := filepath.Join(dir, name)
+		if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+			return candidate, true
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", false
+		}
+		dir = parent
+	}
+}
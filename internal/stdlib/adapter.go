@@ -1,7 +1,158 @@
 package stdlib
 
-import xstdlib "github.com/NonLogicalDev/gofancyimports/internal/stdlib/go_x_stdlib"
+import (
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	xstdlib "github.com/NonLogicalDev/gofancyimports/internal/stdlib/go_x_stdlib"
+)
 
 func IsStdlib(path string) bool {
-	return xstdlib.HasPackage(path)
+	if xstdlib.HasPackage(path) {
+		return true
+	}
+	// Packages that export no symbols (e.g. `time/tzdata`) are only present in the import graph.
+	for range xstdlib.Dependencies(path) {
+		return true
+	}
+	return false
+}
+
+// PackageName returns the package name of a standard library package, which is the
+// last component of its path, skipping major version suffixes (e.g. `math/rand/v2` -> `rand`).
+func PackageName(pkgPath string) string {
+	base := path.Base(pkgPath)
+	if IsMajorVersionSuffix(base) {
+		return path.Base(path.Dir(pkgPath))
+	}
+	return base
+}
+
+// ParseGoVersion parses a Go version as it appears in `go.mod` (`1.21`, `1.21.3`) or in
+// toolchain form (`go1.21`, `go1.22rc1`) and returns its minor version number.
+func ParseGoVersion(version string) (int, bool) {
+	version = strings.TrimPrefix(version, "go")
+	major, rest, ok := strings.Cut(version, ".")
+	if !ok || major != "1" {
+		return 0, false
+	}
+
+	end := 0
+	for end < len(rest) && rest[end] >= '0' && rest[end] <= '9' {
+		end++
+	}
+	minor, err := strconv.Atoi(rest[:end])
+	if err != nil {
+		return 0, false
+	}
+	return minor, true
+}
+
+// LookupPackage returns the standard library package named pkgName that exports every
+// one of the provided symbols as of goVersion (an empty or unparseable version means the
+// latest known release).
+//
+// When several packages qualify (e.g. `math/rand` and `crypto/rand` both export `Int`) the
+// choice is deterministic: the package with the fewest path components wins, then the
+// shortest path, then the lexicographically smallest path.
+func LookupPackage(pkgName string, symbols []string, goVersion string) (string, bool) {
+	maxVersion, hasMaxVersion := ParseGoVersion(goVersion)
+
+	var candidates []string
+	for pkgPath, pkgSymbols := range xstdlib.PackageSymbols {
+		if PackageName(pkgPath) != pkgName {
+			continue
+		}
+
+		exported := make(map[string]bool, len(pkgSymbols))
+		for _, sym := range pkgSymbols {
+			if hasMaxVersion && int(sym.Version) > maxVersion {
+				continue
+			}
+			switch sym.Kind {
+			case xstdlib.Type, xstdlib.Func, xstdlib.Var, xstdlib.Const:
+				exported[sym.Name] = true
+			}
+		}
+		if len(exported) == 0 {
+			continue
+		}
+
+		found := true
+		for _, name := range symbols {
+			if !exported[name] {
+				found = false
+				break
+			}
+		}
+		if found {
+			candidates = append(candidates, pkgPath)
+		}
+	}
+	if len(candidates) == 0 {
+		return "", false
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if da, db := strings.Count(a, "/"), strings.Count(b, "/"); da != db {
+			return da < db
+		}
+		if len(a) != len(b) {
+			return len(a) < len(b)
+		}
+		return a < b
+	})
+	return candidates[0], true
+}
+
+// HasSymbol reports whether the standard library package exports a package level symbol
+// (type, function, variable or constant) with the given name in any known release.
+func HasSymbol(pkgPath string, name string) bool {
+	for _, sym := range xstdlib.PackageSymbols[pkgPath] {
+		switch sym.Kind {
+		case xstdlib.Type, xstdlib.Func, xstdlib.Var, xstdlib.Const:
+			if sym.Name == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// PackageVersion returns the minor Go version that introduced the standard library package,
+// which is the version of its earliest symbol.
+func PackageVersion(pkgPath string) (int, bool) {
+	syms, ok := xstdlib.PackageSymbols[pkgPath]
+	if !ok || len(syms) == 0 {
+		return 0, false
+	}
+	version := int(syms[0].Version)
+	for _, sym := range syms[1:] {
+		version = min(version, int(sym.Version))
+	}
+	return version, true
+}
+
+// SymbolVersion returns the minor Go version that introduced the symbol of the standard
+// library package, named as in the symbol table (`Cut`, `(*Buffer).Grow`, `Header.Name`).
+func SymbolVersion(pkgPath string, name string) (int, bool) {
+	for _, sym := range xstdlib.PackageSymbols[pkgPath] {
+		if sym.Name == name {
+			return int(sym.Version), true
+		}
+	}
+	return 0, false
+}
+
+// IsMajorVersionSuffix reports whether an import path component is a major version
+// suffix (`v2`, `v3`, ...), which is not part of the package name.
+func IsMajorVersionSuffix(s string) bool {
+	if len(s) < 2 || s[0] != 'v' {
+		return false
+	}
+	_, err := strconv.Atoi(s[1:])
+	return err == nil
 }
@@ -2,14 +2,21 @@ package autogroupimports
 
 import (
 	"bytes"
+	"fmt"
+	"go/ast"
 	"go/printer"
+	"go/token"
 	"go/types"
+	"path/filepath"
+	"strconv"
 	"strings"
 
 	"golang.org/x/tools/go/analysis"
 
 	gofancyimports "github.com/NonLogicalDev/gofancyimports"
+	"github.com/NonLogicalDev/gofancyimports/internal/modindex"
 	"github.com/NonLogicalDev/gofancyimports/pkg/organizer/autogroup"
+	gftypes "github.com/NonLogicalDev/gofancyimports/pkg/types"
 )
 
 const _name = "autogroupimports"
@@ -60,6 +67,57 @@ Additionally all un-aliased imports whose base name (last path entry) does not m
 
 		"github.com/NonLogicalDev/gofancyimports/internal/stdlib"
 	)
+
+# Options:
+
+Beyond grouping, the pass can be configured with flags to:
+
+  - add missing imports for unresolved references, resolved from imports of other files of
+    the package, the standard library and packages visible to the module (-add-missing)
+  - add justification comments to side effect imports of well known packages
+    (-blank-comment-defaults) or of the listed ones (-blank-comments)
+  - report side effect imports without a justification comment (-require-blank-comment)
+  - add or remove embed and unsafe imports required by go:embed and go:linkname directives
+    (-directive-imports)
+  - place dot imports (-group-dot) or aliased imports (-group-alias) into separate groups and
+    reorder groups (-group-order)
+  - report dot imports outside of tests (-dot-imports) unless their path is listed
+    (-dot-imports-allow)
+  - place imports of internal packages into a separate group (-group-internal), optionally
+    only those of the current module (-group-internal-module-only)
+  - report imports of internal packages that are not visible to the importing package
+    (-internal-imports)
+  - place the package under test in external test packages (-group-test-package) and test
+    helper packages (-group-test-helpers, -test-helpers) into separate groups
+  - split third party imports into groups by their leading path elements (-group-depth,
+    -group-depth-min-size)
+  - place golang.org/x imports (or those of -x-prefixes) into a separate group right after
+    the standard library (-group-x)
+  - place doc commented groups before, after or among organized groups (-sticky-placement)
+    and keep the order of their imports (-sticky-sort=false)
+  - merge import declarations or split them into one per group (-layout) and write single
+    imports with parenthesis (-single-import-parens)
+  - hoist comments floating between imports, keep them inside the declaration or attach them
+    to the following import (-comments)
+  - remove repeated comments from doc comments of merged import declarations
+    (-dedup-doc-comments)
+  - report comments looking like cgo preambles which cgo ignores, as they are separated from
+    the import of "C" (-cgo-preambles)
+  - alias imports with colliding package names and rename their references
+    (-collision-aliases), or report the collisions (-import-collisions)
+  - report imports shadowed by local identifiers, suggesting an alias (-shadowed-imports)
+  - rewrite references to deprecated standard library symbols (-migrate-deprecated) or to
+    the listed ones (-migrate-symbols), fixing up imports accordingly
+  - import every path under the name most files of the package use, renaming the references
+    (-consistent-aliases)
+
+Directives forcing an import into a group (//gofancyimports:group=<name>) that name an unknown
+group are always reported, as they are otherwise ignored.
+
+Test main packages synthesized by the go tool for packages with tests are skipped, their
+source is generated into the build cache and is neither reported nor fixed. Test files of the
+analyzed packages themselves are analyzed as usual, which the dot import policy relies on to
+tell test files apart (-dot-imports=tests).
 `
 
 var Analyzer = &analysis.Analyzer{
@@ -77,6 +135,56 @@ var (
 
 	argSideEffectGroup bool
 
+	argAddMissing bool
+
+	argRequireBlankComment bool
+	argBlankComments       string
+	argBlankCommentDefault bool
+
+	argDirectiveImports bool
+
+	argGroupOrder string
+
+	argAliasGroup bool
+
+	argXGroup    bool
+	argXPrefixes string
+
+	argInternalGroup           bool
+	argInternalGroupModuleOnly bool
+
+	argGroupDepth        int
+	argGroupDepthMinSize int
+
+	argStickyPlacement string
+	argStickySort      bool
+
+	argCommentPlacement string
+	argDedupDocComments bool
+
+	argCollisionAliases  string
+	argImportCollisions  bool
+	argShadowedImports   bool
+	argConsistentAliases bool
+
+	argMigrateDeprecated bool
+	argMigrateSymbols    string
+
+	argLayout             string
+	argSingleImportParens bool
+
+	argTestPackageGroup string
+	argTestHelperGroup  bool
+	argTestHelpers      string
+
+	argDotImportGroup  bool
+	argDotImports      string
+	argDotImportsAllow string
+
+	argInternalImports bool
+
+	argCgoPreambles bool
+
 	_defaultPrintConfig = &printer.Config{Mode: printer.UseSpaces | printer.TabIndent, Tabwidth: 8}
 )
 
@@ -90,9 +198,112 @@ func init() {
 	Analyzer.Flags.BoolVar(&argSideEffectGroup,
 		"group-effect", false,
 		"separate side effect imports into separate group")
+	Analyzer.Flags.BoolVar(&argAddMissing,
+		"add-missing", false,
+		"add missing imports (standard library and packages visible to the module)")
+	Analyzer.Flags.BoolVar(&argRequireBlankComment,
+		"require-blank-comment", false,
+		"report side effect imports that have no justification comment")
+	Analyzer.Flags.StringVar(&argBlankComments,
+		"blank-comments", "",
+		"comma separated list of default justification comments for side effect imports of packages (path=comment)")
+	Analyzer.Flags.BoolVar(&argBlankCommentDefault,
+		"blank-comment-defaults", false,
+		"add default justification comments to side effect imports of well known packages (pprof, tzdata, database drivers, ...)")
+	Analyzer.Flags.BoolVar(&argDirectiveImports,
+		"directive-imports", false,
+		"manage embed and unsafe imports required by go:embed and go:linkname directives")
+	Analyzer.Flags.StringVar(&argGroupOrder,
+		"group-order", "",
+		"comma separated order of groups (std, x, nodot, thirdparty, testhelper, local, internal, effect, dot, alias)")
+	Analyzer.Flags.BoolVar(&argAliasGroup,
+		"group-alias", false,
+		"separate aliased imports into separate group")
+	Analyzer.Flags.BoolVar(&argXGroup,
+		"group-x", false,
+		"separate golang.org/x imports into separate group right after standard library")
+	Analyzer.Flags.StringVar(&argXPrefixes,
+		"x-prefixes", "",
+		"comma separated list of import path prefixes of the x group overriding the default (golang.org/x/)")
+	Analyzer.Flags.BoolVar(&argInternalGroup,
+		"group-internal", false,
+		"separate imports of internal packages into separate group")
+	Analyzer.Flags.BoolVar(&argInternalGroupModuleOnly,
+		"group-internal-module-only", false,
+		"only separate imports of internal packages of the current module")
+	Analyzer.Flags.BoolVar(&argInternalImports,
+		"internal-imports", false,
+		"report imports of internal packages that are not visible to the importing package")
+	Analyzer.Flags.BoolVar(&argCgoPreambles,
+		"cgo-preambles", false,
+		"report comments looking like cgo preambles that are detached from the import of \"C\"")
+	Analyzer.Flags.IntVar(&argGroupDepth,
+		"group-depth", 0,
+		"split third party imports into groups by the first N path elements (1: host, 2: host/org)")
+	Analyzer.Flags.IntVar(&argGroupDepthMinSize,
+		"group-depth-min-size", 2,
+		"minimum number of imports for a third party sub-group to be split out")
+	Analyzer.Flags.StringVar(&argStickyPlacement,
+		"sticky-placement", "after",
+		"placement of doc commented groups relative to organized groups: after, before or interleaved")
+	Analyzer.Flags.BoolVar(&argStickySort,
+		"sticky-sort", true,
+		"sort imports inside doc commented groups")
+	Analyzer.Flags.StringVar(&argCommentPlacement,
+		"comments", "hoist",
+		"placement of comments floating between imports: hoist, inside or attach")
+	Analyzer.Flags.BoolVar(&argDedupDocComments,
+		"dedup-doc-comments", false,
+		"remove repeated comments from doc comments of merged import blocks")
+	Analyzer.Flags.StringVar(&argCollisionAliases,
+		"collision-aliases", "off",
+		"alias imports with colliding package names and rename their references: off, initial or parent")
+	Analyzer.Flags.BoolVar(&argImportCollisions,
+		"import-collisions", false,
+		"report imports with colliding package names left unresolved when collision-aliases is off")
+	Analyzer.Flags.BoolVar(&argConsistentAliases,
+		"consistent-aliases", false,
+		"import every path under the name most files of the package use, renaming the references")
+	Analyzer.Flags.BoolVar(&argMigrateDeprecated,
+		"migrate-deprecated", false,
+		"rewrite references to deprecated standard library symbols (e.g. ioutil.ReadFile to os.ReadFile)")
+	Analyzer.Flags.StringVar(&argMigrateSymbols,
+		"migrate-symbols", "",
+		"comma separated list of symbol migrations (old/path.Name=new/path.Name)")
+	Analyzer.Flags.BoolVar(&argShadowedImports,
+		"shadowed-imports", false,
+		"report imports shadowed by local identifiers, suggesting an alias derived by the collision alias scheme (parent if off)")
+	Analyzer.Flags.StringVar(&argLayout,
+		"layout", "merge-undocumented",
+		"import declaration layout: merge-undocumented, merge-all or one-per-group")
+	Analyzer.Flags.BoolVar(&argSingleImportParens,
+		"single-import-parens", false,
+		"write declarations with a single import with parenthesis")
+	Analyzer.Flags.StringVar(&argTestPackageGroup,
+		"group-test-package", "inline",
+		"placement of the package under test import in external test packages: inline, leading or trailing")
+	Analyzer.Flags.BoolVar(&argTestHelperGroup,
+		"group-test-helpers", false,
+		"separate imports of test helper packages in _test.go files into separate group")
+	Analyzer.Flags.StringVar(&argTestHelpers,
+		"test-helpers", "",
+		"comma separated list of test helper packages (or path/... patterns) overriding the default list")
+	Analyzer.Flags.BoolVar(&argDotImportGroup,
+		"group-dot", false,
+		"separate dot imports into separate group")
+	Analyzer.Flags.StringVar(&argDotImports,
+		"dot-imports", "any",
+		"where dot imports are allowed: any, tests (only in _test.go files) or none")
+	Analyzer.Flags.StringVar(&argDotImportsAllow,
+		"dot-imports-allow", "",
+		"comma separated list of import paths (or path/... patterns) that may always be dot imported")
 }
 
 func run(pass *analysis.Pass) (interface{}, error) {
+	if isTestMain(pass.Pkg) {
+		return nil, nil
+	}
+
 	localPrefixes := strings.Split(argLocalPrefix, ",")
 
 	pkgInfo := map[string]*types.Package{}
@@ -103,47 +314,437 @@ func run(pass *analysis.Pass) (interface{}, error) {
 		}
 	}
 
-	transform := autogroup.New(
+	blankComments, err := autogroup.BlankImportComments(argBlankCommentDefault, splitList(argBlankComments))
+	if err != nil {
+		return nil, err
+	}
+
+	transformOpts := []autogroup.Option{
 		autogroup.WithLocalPrefixGroup(localPrefixes),
-		autogroup.WithSpecFixups(autogroup.DefaultSpecFixups(pkgInfo)...),
+		autogroup.WithSpecFixups(
+			autogroup.FixupBlankImportComments(blankComments),
+			autogroup.FixupDefaultImportAlias(pkgInfo),
+		),
 		autogroup.WithNoDotGroupEnabled(argNoDotGroup),
 		autogroup.WithSideEffectGroupEnabled(argSideEffectGroup),
+		autogroup.WithDirectiveImports(argDirectiveImports),
+		autogroup.WithDotImportGroup(argDotImportGroup),
+		autogroup.WithAliasGroup(argAliasGroup),
+		autogroup.WithExtendedStdlibGroup(argXGroup),
+		autogroup.WithInternalGroup(argInternalGroup),
+		autogroup.WithGroupByPathDepth(argGroupDepth),
+		autogroup.WithGroupByPathMinSize(argGroupDepthMinSize),
+	}
+	stickyPlacement, ok := autogroup.ParseStickyGroupPlacement(argStickyPlacement)
+	if !ok {
+		return nil, fmt.Errorf("invalid sticky group placement %q: expected after, before or interleaved", argStickyPlacement)
+	}
+	transformOpts = append(transformOpts,
+		autogroup.WithStickyGroupPlacement(stickyPlacement),
+		autogroup.WithStickyGroupSorting(argStickySort),
+	)
+	commentPlacement, ok := autogroup.ParseCommentPlacement(argCommentPlacement)
+	if !ok {
+		return nil, fmt.Errorf("invalid comment placement %q: expected hoist, inside or attach", argCommentPlacement)
+	}
+	transformOpts = append(transformOpts,
+		autogroup.WithCommentPlacement(commentPlacement),
+		autogroup.WithDocCommentDedup(argDedupDocComments),
+	)
+	aliasScheme, ok := autogroup.ParseAliasScheme(argCollisionAliases)
+	if !ok {
+		return nil, fmt.Errorf("invalid collision alias scheme %q: expected off, initial or parent", argCollisionAliases)
+	}
+	transformOpts = append(transformOpts,
+		autogroup.WithCollisionAliases(aliasScheme),
+		autogroup.WithPackageTypes(pkgInfo),
+	)
+	dotImportPolicy, ok := autogroup.ParseDotImportPolicy(argDotImports)
+	if !ok {
+		return nil, fmt.Errorf("invalid dot import policy %q: expected any, tests or none", argDotImports)
+	}
+	if dotImportPolicy != nil {
+		dotImportPolicy.Allowed = splitList(argDotImportsAllow)
+	}
+	layout, ok := autogroup.ParseDeclarationLayout(argLayout)
+	if !ok {
+		return nil, fmt.Errorf("invalid layout %q: expected merge-undocumented, merge-all or one-per-group", argLayout)
+	}
+	transformOpts = append(transformOpts, autogroup.WithDeclarationLayout(layout))
+	if argSingleImportParens {
+		transformOpts = append(transformOpts, autogroup.WithSingleImportStyle(autogroup.SingleImportParenthesized))
+	}
+	placement, ok := autogroup.ParsePackageUnderTestPlacement(argTestPackageGroup)
+	if !ok {
+		return nil, fmt.Errorf("invalid package under test placement %q: expected inline, leading or trailing", argTestPackageGroup)
+	}
+	transformOpts = append(transformOpts,
+		autogroup.WithPackageUnderTestGroup(placement),
+		autogroup.WithTestHelperGroup(argTestHelperGroup),
+	)
+	if argXPrefixes != "" {
+		transformOpts = append(transformOpts, autogroup.WithExtendedStdlibPrefixes(splitList(argXPrefixes)))
+	}
+	if argTestHelpers != "" {
+		transformOpts = append(transformOpts, autogroup.WithTestHelperPackages(splitList(argTestHelpers)))
+	}
+	if pass.Pkg != nil {
+		transformOpts = append(transformOpts, autogroup.WithPackagePath(pass.Pkg.Path()))
+	}
+	if argInternalGroupModuleOnly && pass.Module != nil {
+		transformOpts = append(transformOpts, autogroup.WithInternalGroupModule(pass.Module.Path))
+	}
+	if argGroupOrder != "" {
+		order, err := autogroup.ParseGroupOrder(strings.Split(argGroupOrder, ","))
+		if err != nil {
+			return nil, err
+		}
+		transformOpts = append(transformOpts, autogroup.WithGroupOrder(order...))
+	}
+	if argAddMissing && pass.Pkg != nil {
+		transformOpts = append(transformOpts,
+			autogroup.WithPackageDeclarations(pass.Pkg.Scope().Names()),
+		)
+	}
+	if argMigrateDeprecated || argMigrateSymbols != "" {
+		migrations := map[string]string{}
+		if argMigrateDeprecated {
+			for from, to := range autogroup.DefaultStdlibMigrations {
+				migrations[from] = to
+			}
+		}
+		for _, entry := range splitList(argMigrateSymbols) {
+			from, to, ok := strings.Cut(entry, "=")
+			if !ok {
+				return nil, fmt.Errorf("invalid symbol migration %q: expected old/path.Name=new/path.Name", entry)
+			}
+			migrations[strings.TrimSpace(from)] = strings.TrimSpace(to)
+		}
+
+		var goVersion string
+		if pass.Pkg != nil {
+			goVersion = pass.Pkg.GoVersion()
+		}
+		transformOpts = append(transformOpts, autogroup.WithSymbolMigrations(goVersion, migrations))
+	}
+
+	var (
+		files []*ast.File
+		srcs  [][]byte
 	)
 	for _, file := range pass.Files {
+		if argRequireBlankComment {
+			reportUnjustifiedBlankImports(pass, file, blankComments)
+		}
+		reportUnknownGroupDirectives(pass, file)
+		if dotImportPolicy != nil {
+			reportForbiddenDotImports(pass, file, *dotImportPolicy)
+		}
+		if argInternalImports && pass.Pkg != nil {
+			reportForbiddenInternalImports(pass, file)
+		}
+		if argCgoPreambles {
+			reportDetachedCgoPreambles(pass, file)
+		}
+		if argImportCollisions && aliasScheme == nil {
+			reportImportNameCollisions(pass, file, pkgInfo)
+		}
+		if argShadowedImports {
+			shadowScheme := aliasScheme
+			if shadowScheme == nil {
+				shadowScheme = autogroup.AliasParentDir
+			}
+			reportShadowedImports(pass, file, shadowScheme)
+		}
+
 		b := bytes.NewBuffer(nil)
 		err := _defaultPrintConfig.Fprint(b, pass.Fset, file)
 		if err != nil {
 			pass.Reportf(file.Pos(), "error while loading file: %v", err)
 			continue
 		}
-
-		edits, err := gofancyimports.RewriteImportsAST(pass.Fset, file, b.Bytes(),
-			gofancyimports.WithTransform(transform),
-			gofancyimports.WithPrinterConfig(_defaultPrintConfig),
-		)
-		if err != nil {
+		if _, err := gofancyimports.ParseImportDeclarations(pass.Fset, file); err != nil {
 			pass.Reportf(file.Pos(), "error while parsing imports: %v", err)
 			continue
 		}
+		files = append(files, file)
+		srcs = append(srcs, b.Bytes())
+	}
+	if len(files) == 0 {
+		return nil, nil
+	}
+
+	var fileOpts autogroup.FileOptions
+	if argAddMissing && pass.Pkg != nil {
+		fileOpts = func(file gftypes.PackageFile) []autogroup.Option {
+			return []autogroup.Option{missingImportResolvers(pass, file.File)}
+		}
+	}
+	transform := autogroup.NewPackage(fileOpts,
+		append(transformOpts, autogroup.WithConsistentAliases(argConsistentAliases))...,
+	)
+
+	packageEdits, err := gofancyimports.RewritePackageImportsAST(pass.Fset, files, srcs, transform,
+		gofancyimports.WithPrinterConfig(_defaultPrintConfig),
+	)
+	if err != nil {
+		pass.Reportf(files[0].Pos(), "error while rewriting imports: %v", err)
+		return nil, nil
+	}
+
+	for _, edits := range packageEdits {
+		if len(edits) == 0 {
+			continue
+		}
 
+		// Edits renaming references to aliased imports have to be applied along with the
+		// rewrite of import declarations, hence all edits belong to a single fix.
+		textEdits := make([]analysis.TextEdit, 0, len(edits))
 		for _, edit := range edits {
-			edit := edit
+			textEdits = append(textEdits, *edit)
+		}
+		pass.Report(analysis.Diagnostic{
+			Pos: edits[0].Pos,
+			End: edits[0].End,
+
+			Category: "imports",
+			Message:  "go imports are not properly formatted",
+
+			SuggestedFixes: []analysis.SuggestedFix{
+				{
+					Message:   "format imports",
+					TextEdits: textEdits,
+				},
+			},
+		})
+	}
+
+	return nil, nil
+}
+
+// isTestMain reports whether the package is the test main synthesized by the go tool when
+// test variants of packages are loaded (as done by analysistest and by checkers unless run
+// with -test=false), i.e. whenever dot imports of test files are checked against the policy.
+// Its only file lives in the build cache, so it is never reported or fixed.
+func isTestMain(pkg *types.Package) bool {
+	return pkg != nil && pkg.Name() == "main" && strings.HasSuffix(pkg.Path(), ".test")
+}
+
+// missingImportResolvers returns the resolvers of missing imports for the file, resolving
+// references from imports of other files in the package first, then from the standard library,
+// and finally from packages visible to the module of the file.
+func missingImportResolvers(pass *analysis.Pass, file *ast.File) autogroup.Option {
+	var (
+		siblingImports []*ast.ImportSpec
+		siblingPaths   []string
+	)
+	for _, sibling := range pass.Files {
+		if sibling == file {
+			continue
+		}
+		for _, spec := range sibling.Imports {
+			siblingImports = append(siblingImports, spec)
+			if p, err := strconv.Unquote(spec.Path.Value); err == nil {
+				siblingPaths = append(siblingPaths, p)
+			}
+		}
+	}
+
+	resolvers := []autogroup.ImportResolver{
+		autogroup.ResolveFromImports(siblingImports),
+		autogroup.ResolveStdlibImports(pass.Pkg.GoVersion()),
+	}
+
+	fileDir := filepath.Dir(pass.Fset.Position(file.Package).Filename)
+	if goModPath, ok := modindex.FindGoMod(fileDir); ok {
+		if idx, err := modindex.LoadCached(goModPath); err == nil {
+			resolvers = append(resolvers, idx.Resolver(fileDir, siblingPaths))
+		}
+	}
+	return autogroup.WithMissingImportResolvers(resolvers...)
+}
+
+// reportUnjustifiedBlankImports reports side effect imports that have no comment justifying
+// them and no default comment in the comments table to be added by a fixup.
+func reportUnjustifiedBlankImports(pass *analysis.Pass, file *ast.File, comments map[string]string) {
+	for _, decl := range file.Decls {
+		genDecl, ok := decl.(*ast.GenDecl)
+		if !ok || genDecl.Tok != token.IMPORT {
+			continue
+		}
+		// Doc comment of a single import declaration is the doc comment of its spec.
+		if genDecl.Lparen == token.NoPos && genDecl.Doc != nil {
+			continue
+		}
+
+		for _, spec := range genDecl.Specs {
+			spec := spec.(*ast.ImportSpec)
+			if spec.Name == nil || spec.Name.Name != "_" || spec.Doc != nil || spec.Comment != nil {
+				continue
+			}
+			specPath, _ := strconv.Unquote(spec.Path.Value)
+			if _, ok := comments[specPath]; ok {
+				continue
+			}
 			pass.Report(analysis.Diagnostic{
-				Pos: edit.Pos,
-				End: edit.End,
+				Pos: spec.Pos(),
+				End: spec.End(),
 
 				Category: "imports",
-				Message:  "go imports are not properly formatted",
+				Message:  fmt.Sprintf("side effect import of %q should have a comment justifying it", specPath),
+			})
+		}
+	}
+}
 
-				SuggestedFixes: []analysis.SuggestedFix{
-					{
-						Message:   "format imports",
-						TextEdits: []analysis.TextEdit{*edit},
-					},
-				},
+// reportUnknownGroupDirectives reports `//gofancyimports:group=<name>` directives naming
+// unknown groups, which are otherwise silently ignored.
+func reportUnknownGroupDirectives(pass *analysis.Pass, file *ast.File) {
+	for _, spec := range autogroup.UnknownGroupDirectives(file) {
+		name, _ := gftypes.DirectiveValue(spec.Comment, gftypes.DirectiveGroup)
+		pass.Report(analysis.Diagnostic{
+			Pos: spec.Comment.Pos(),
+			End: spec.Comment.End(),
+
+			Category: "imports",
+			Message:  fmt.Sprintf("unknown group %q in directive of import %s", name, spec.Path.Value),
+		})
+	}
+}
+
+// reportForbiddenDotImports reports dot imports that are not allowed by the dot import policy.
+func reportForbiddenDotImports(pass *analysis.Pass, file *ast.File, policy autogroup.DotImportPolicy) {
+	filename := pass.Fset.Position(file.Package).Filename
+	for _, spec := range file.Imports {
+		if spec.Name == nil || spec.Name.Name != "." {
+			continue
+		}
+		specPath, _ := strconv.Unquote(spec.Path.Value)
+		if policy.Allows(filename, specPath) {
+			continue
+		}
+		pass.Report(analysis.Diagnostic{
+			Pos: spec.Pos(),
+			End: spec.End(),
+
+			Category: "imports",
+			Message:  fmt.Sprintf("dot import of %q is not allowed here", specPath),
+		})
+	}
+}
+
+// reportForbiddenInternalImports reports imports of internal packages that are not visible
+// to the package being analyzed, which otherwise only surface at build time. Files named on
+// the command line are not reported, as the path synthesized for their package tells nothing
+// about their location.
+func reportForbiddenInternalImports(pass *analysis.Pass, file *ast.File) {
+	// External test packages share visibility with the package they are testing.
+	importerPath := strings.TrimSuffix(pass.Pkg.Path(), "_test")
+	if importerPath == "command-line-arguments" {
+		return
+	}
+
+	for _, spec := range file.Imports {
+		specPath, _ := strconv.Unquote(spec.Path.Value)
+		if autogroup.InternalImportAllowed(importerPath, specPath) {
+			continue
+		}
+		pass.Report(analysis.Diagnostic{
+			Pos: spec.Pos(),
+			End: spec.End(),
+
+			Category: "imports",
+			Message:  fmt.Sprintf("use of internal package %q not allowed", specPath),
+		})
+	}
+}
+
+// reportImportNameCollisions reports imports that are available in the file under the same
+// name, which is a compile error unless all but one of them get aliased.
+func reportImportNameCollisions(pass *analysis.Pass, file *ast.File, pkgInfo map[string]*types.Package) {
+	for name, specs := range autogroup.ImportNameCollisions(file.Imports, pkgInfo) {
+		for _, spec := range specs[1:] {
+			pass.Report(analysis.Diagnostic{
+				Pos: spec.Pos(),
+				End: spec.End(),
+
+				Category: "imports",
+				Message:  fmt.Sprintf("import of %s collides with import of %s under the name %q", spec.Path.Value, specs[0].Path.Value, name),
 			})
 		}
 	}
+}
 
-	return nil, nil
+// reportDetachedCgoPreambles reports comments looking like cgo preambles which cgo ignores,
+// because they are separated from the import of "C" by a blank line or document a
+// declaration importing other packages as well.
+func reportDetachedCgoPreambles(pass *analysis.Pass, file *ast.File) {
+	report := func(cg *ast.CommentGroup, reason string) {
+		pass.Report(analysis.Diagnostic{
+			Pos: cg.Pos(),
+			End: cg.End(),
+
+			Category: "imports",
+			Message:  fmt.Sprintf("cgo preamble is detached from import of \"C\": %s", reason),
+		})
+	}
+
+	prevEnd := file.Name.End()
+	for _, decl := range file.Decls {
+		genDecl, ok := decl.(*ast.GenDecl)
+		if !ok || genDecl.Tok != token.IMPORT {
+			prevEnd = decl.End()
+			continue
+		}
+
+		for _, spec := range genDecl.Specs {
+			spec := spec.(*ast.ImportSpec)
+			if spec.Path.Value != `"C"` || spec.Doc != nil {
+				continue
+			}
+			if len(genDecl.Specs) > 1 {
+				if genDecl.Doc != nil && looksLikeCgoPreamble(genDecl.Doc) {
+					report(genDecl.Doc, "the declaration imports other packages")
+				}
+				continue
+			}
+			if genDecl.Doc != nil {
+				continue
+			}
+
+			// The comment closest to the import, separated from it by a blank line.
+			var detached *ast.CommentGroup
+			for _, cg := range file.Comments {
+				if cg.Pos() > prevEnd && cg.End() < genDecl.Pos() {
+					detached = cg
+				}
+			}
+			if detached != nil && looksLikeCgoPreamble(detached) {
+				report(detached, "it is separated from the import by a blank line")
+			}
+		}
+		prevEnd = genDecl.End()
+	}
+}
+
+// looksLikeCgoPreamble reports whether the comment contains cgo directives or C preprocessor
+// directives.
+func looksLikeCgoPreamble(cg *ast.CommentGroup) bool {
+	for _, line := range strings.Split(cg.Text(), "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "#") {
+			return true
+		}
+	}
+	return false
+}
+
+// splitList splits a comma separated flag value, dropping empty entries.
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
 }
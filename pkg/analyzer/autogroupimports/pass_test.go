@@ -0,0 +1,96 @@
+package autogroupimports_test
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"golang.org/x/tools/go/analysis/analysistest"
+
+	"github.com/NonLogicalDev/gofancyimports/pkg/analyzer/autogroupimports"
+)
+
+func TestUnjustifiedBlankImports(t *testing.T) {
+	setFlags(t, map[string]string{
+		"require-blank-comment":  "true",
+		"blank-comment-defaults": "true",
+		"blank-comments":         "plugins/metrics=register metrics exporters",
+	})
+	analysistest.RunWithSuggestedFixes(t, analysistest.TestData(), autogroupimports.Analyzer, "blankcomments")
+}
+
+func TestShadowedImports(t *testing.T) {
+	setFlags(t, map[string]string{"shadowed-imports": "true"})
+	analysistest.RunWithSuggestedFixes(t, analysistest.TestData(), autogroupimports.Analyzer, "shadow")
+}
+
+func TestImportNameCollisions(t *testing.T) {
+	setFlags(t, map[string]string{"import-collisions": "true"})
+	analysistest.Run(t, analysistest.TestData(), autogroupimports.Analyzer, "collisions")
+}
+
+func TestDetachedCgoPreambles(t *testing.T) {
+	if os.Getenv("CGO_ENABLED") == "0" {
+		t.Skip("cgo is disabled")
+	}
+	setFlags(t, map[string]string{"cgo-preambles": "true"})
+	analysistest.Run(t, analysistest.TestData(), autogroupimports.Analyzer, "cgopreamble")
+}
+
+func TestUnknownGroupDirectives(t *testing.T) {
+	analysistest.Run(t, analysistest.TestData(), autogroupimports.Analyzer, "groupdirectives")
+}
+
+func TestForbiddenDotImports(t *testing.T) {
+	setFlags(t, map[string]string{
+		"dot-imports":       "tests",
+		"dot-imports-allow": "math",
+	})
+	analysistest.Run(t, analysistest.TestData(), autogroupimports.Analyzer, "dotimports")
+}
+
+func TestInvalidDotImportPolicy(t *testing.T) {
+	setFlags(t, map[string]string{"dot-imports": "nnone"})
+
+	var errs errorRecorder
+	analysistest.Run(&errs, analysistest.TestData(), autogroupimports.Analyzer, "dotimports")
+	if !strings.Contains(strings.Join(errs, "\n"), `invalid dot import policy "nnone"`) {
+		t.Fatalf("expected an error for an unknown dot import policy, got: %q", errs)
+	}
+}
+
+func TestForbiddenInternalImports(t *testing.T) {
+	setFlags(t, map[string]string{"internal-imports": "true"})
+	analysistest.Run(t, analysistest.TestData(), autogroupimports.Analyzer, "internals/...")
+}
+
+func TestForbiddenInternalImportsCommandLineFiles(t *testing.T) {
+	setFlags(t, map[string]string{"internal-imports": "true"})
+	analysistest.Run(t, analysistest.TestData(), autogroupimports.Analyzer, "./src/cmdline/main.go")
+}
+
+// setFlags sets flags of the analyzer for the duration of the test.
+func setFlags(t *testing.T, values map[string]string) {
+	t.Helper()
+	for name, value := range values {
+		flag := autogroupimports.Analyzer.Flags.Lookup(name)
+		if flag == nil {
+			t.Fatalf("unknown flag %q", name)
+		}
+		previous := flag.Value.String()
+		if err := flag.Value.Set(value); err != nil {
+			t.Fatalf("setting flag %q: %v", name, err)
+		}
+		t.Cleanup(func() {
+			_ = flag.Value.Set(previous)
+		})
+	}
+}
+
+// errorRecorder records errors reported by analysistest, for tests expecting the analyzer to fail.
+type errorRecorder []string
+
+func (r *errorRecorder) Errorf(format string, args ...any) {
+	*r = append(*r, fmt.Sprintf(format, args...))
+}
@@ -0,0 +1,146 @@
+package autogroupimports
+
+import (
+	"fmt"
+	"go/ast"
+	"go/types"
+	"sort"
+	"strconv"
+
+	"golang.org/x/tools/go/analysis"
+
+	"github.com/NonLogicalDev/gofancyimports/pkg/organizer/autogroup"
+)
+
+// reportShadowedImports reports imports whose names are shadowed by local identifiers in any
+// scope of the file, suggesting to alias the import (and rename its references) using the
+// alias scheme.
+func reportShadowedImports(pass *analysis.Pass, file *ast.File, scheme autogroup.AliasScheme) {
+	if pass.TypesInfo == nil || pass.Pkg == nil {
+		return
+	}
+
+	imported := map[string]*ast.ImportSpec{}
+	for _, spec := range file.Imports {
+		if pkgName := importedPkgName(pass.TypesInfo, spec); pkgName != nil {
+			imported[pkgName.Name()] = spec
+		}
+	}
+	if len(imported) == 0 {
+		return
+	}
+
+	// Identifiers declared in the file, in the order of the source.
+	var defs []*ast.Ident
+	for ident, obj := range pass.TypesInfo.Defs {
+		if obj != nil && ident.Pos() >= file.Pos() && ident.End() <= file.End() {
+			defs = append(defs, ident)
+		}
+	}
+	sort.Slice(defs, func(i, j int) bool {
+		return defs[i].Pos() < defs[j].Pos()
+	})
+
+	taken := map[string]bool{}
+	for _, name := range pass.Pkg.Scope().Names() {
+		taken[name] = true
+	}
+	for name := range imported {
+		taken[name] = true
+	}
+	for _, ident := range defs {
+		taken[ident.Name] = true
+	}
+
+	reported := map[*ast.ImportSpec]bool{}
+	for _, ident := range defs {
+		obj := pass.TypesInfo.Defs[ident]
+		spec, ok := imported[obj.Name()]
+		if !ok || reported[spec] || !isLocalObject(pass.Pkg, obj) {
+			continue
+		}
+		reported[spec] = true
+
+		specPath, _ := strconv.Unquote(spec.Path.Value)
+		alias := scheme.Alias(specPath, obj.Name(), taken)
+		taken[alias] = true
+
+		pass.Report(analysis.Diagnostic{
+			Pos: ident.Pos(),
+			End: ident.End(),
+
+			Category: "imports",
+			Message:  fmt.Sprintf("import of %q is shadowed by %s %s", specPath, objectKind(obj), obj.Name()),
+
+			SuggestedFixes: []analysis.SuggestedFix{
+				{
+					Message:   fmt.Sprintf("import %q as %s", specPath, alias),
+					TextEdits: aliasImportEdits(pass.TypesInfo, file, spec, alias),
+				},
+			},
+		})
+	}
+}
+
+// aliasImportEdits returns edits aliasing the import and renaming its references in the file.
+func aliasImportEdits(info *types.Info, file *ast.File, spec *ast.ImportSpec, alias string) []analysis.TextEdit {
+	var edits []analysis.TextEdit
+	if spec.Name != nil {
+		edits = append(edits, analysis.TextEdit{Pos: spec.Name.Pos(), End: spec.Name.End(), NewText: []byte(alias)})
+	} else {
+		edits = append(edits, analysis.TextEdit{Pos: spec.Path.Pos(), End: spec.Path.Pos(), NewText: []byte(alias + " ")})
+	}
+
+	pkgName := importedPkgName(info, spec)
+	ast.Inspect(file, func(n ast.Node) bool {
+		if ident, ok := n.(*ast.Ident); ok && info.Uses[ident] == pkgName {
+			edits = append(edits, analysis.TextEdit{Pos: ident.Pos(), End: ident.End(), NewText: []byte(alias)})
+		}
+		return true
+	})
+	return edits
+}
+
+// importedPkgName returns the package name object declared by the import, or nil for side
+// effect and dot imports.
+func importedPkgName(info *types.Info, spec *ast.ImportSpec) *types.PkgName {
+	var obj types.Object
+	if spec.Name != nil {
+		obj = info.Defs[spec.Name]
+	} else {
+		obj = info.Implicits[spec]
+	}
+	pkgName, _ := obj.(*types.PkgName)
+	return pkgName
+}
+
+// isLocalObject reports whether the object is declared in a local scope, where it may shadow
+// imports. Fields, methods and labels live in no scope and never shadow imports.
+func isLocalObject(pkg *types.Package, obj types.Object) bool {
+	if _, ok := obj.(*types.Label); ok {
+		return false
+	}
+	if _, ok := obj.(*types.PkgName); ok {
+		return false
+	}
+	parent := obj.Parent()
+	return parent != nil && parent != pkg.Scope() && parent.Parent() != pkg.Scope()
+}
+
+func objectKind(obj types.Object) string {
+	switch obj := obj.(type) {
+	case *types.Var:
+		if obj.IsField() {
+			return "field"
+		}
+		return "variable"
+	case *types.Const:
+		return "constant"
+	case *types.TypeName:
+		return "type"
+	case *types.Func:
+		return "function"
+	default:
+		return "identifier"
+	}
+}
@@ -0,0 +1,9 @@
+package blankcomments
+
+// want +2 `go imports are not properly formatted`
+
+import (
+	_ "image/png"
+
+	_ "plugins/metrics"
+)
@@ -0,0 +1,5 @@
+package blankcomments
+
+// want +2 `side effect import of "plugins/tracing" should have a comment justifying it`
+
+import _ "plugins/tracing"
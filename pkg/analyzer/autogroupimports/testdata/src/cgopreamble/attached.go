@@ -0,0 +1,8 @@
+package cgopreamble
+
+// #include <string.h>
+import "C"
+
+func Len(s string) int {
+	return int(C.strlen(C.CString(s)))
+}
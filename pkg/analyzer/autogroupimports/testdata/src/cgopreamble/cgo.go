@@ -0,0 +1,9 @@
+package cgopreamble
+
+// #include <stdlib.h> // want `cgo preamble is detached from import of "C": it is separated from the import by a blank line`
+
+import "C"
+
+func Abs(n int) int {
+	return int(C.abs(C.int(n)))
+}
@@ -0,0 +1,11 @@
+package cgopreamble
+
+// #include <stdio.h> // want `cgo preamble is detached from import of "C": the declaration imports other packages` `go imports are not properly formatted`
+import (
+	"C"
+	"fmt"
+)
+
+func Print(s string) {
+	fmt.Println(s)
+}
@@ -0,0 +1,11 @@
+package main
+
+import (
+	"fmt"
+
+	"internals/lib/internal/secret"
+)
+
+func main() {
+	fmt.Println(secret.Key)
+}
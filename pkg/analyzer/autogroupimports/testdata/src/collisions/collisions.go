@@ -0,0 +1,14 @@
+package collisions
+
+import (
+	"crypto/rand"
+	htmltemplate "html/template"
+	"math/rand" // want `import of "math/rand" collides with import of "crypto/rand" under the name "rand"`
+	"text/template"
+)
+
+var (
+	_ = rand.Reader
+	_ = template.New
+	_ = htmltemplate.New
+)
@@ -0,0 +1,10 @@
+package dotimports
+
+import (
+	. "math"
+	. "strings" // want `dot import of "strings" is not allowed here`
+)
+
+func Root(s string) (float64, string) {
+	return Sqrt(4), ToUpper(s)
+}
@@ -0,0 +1,12 @@
+package dotimports
+
+import (
+	. "strings"
+	"testing"
+)
+
+func TestRoot(t *testing.T) {
+	if _, s := Root("a"); s != ToUpper("a") {
+		t.Fail()
+	}
+}
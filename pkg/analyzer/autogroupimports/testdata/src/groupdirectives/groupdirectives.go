@@ -0,0 +1,11 @@
+package groupdirectives
+
+import (
+	"fmt"
+	"os"      // gofancyimports:group=sdt // want `unknown group "sdt" in directive of import "os"`
+	"strings" // gofancyimports:group=std
+)
+
+func Name() string {
+	return fmt.Sprint(os.Args[0], strings.ToUpper("a"))
+}
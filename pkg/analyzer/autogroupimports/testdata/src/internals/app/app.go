@@ -0,0 +1,10 @@
+package app
+
+import (
+	"internals/lib"
+	"internals/lib/internal/secret" // want `use of internal package "internals/lib/internal/secret" not allowed`
+)
+
+func Keys() []string {
+	return []string{lib.Key(), secret.Key}
+}
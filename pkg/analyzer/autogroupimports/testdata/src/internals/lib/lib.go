@@ -0,0 +1,7 @@
+package lib
+
+import "internals/lib/internal/secret"
+
+func Key() string {
+	return secret.Key
+}
@@ -0,0 +1,32 @@
+package shadow
+
+import (
+	"encoding/json"
+	"net/url"
+	"strings"
+)
+
+type Link struct {
+	strings []string
+}
+
+func Parse(url string) (*Link, error) { // want `import of "net/url" is shadowed by variable url`
+	var l Link
+	if err := json.Unmarshal([]byte(url), &l); err != nil {
+		return nil, err
+	}
+	return &l, nil
+}
+
+func Encode(l *Link) string {
+	json := strings.Join(l.strings, ",") // want `import of "encoding/json" is shadowed by variable json`
+	return json
+}
+
+func Escape(s string) string {
+	return url.QueryEscape(s)
+}
+
+func (l *Link) Join() string {
+	return strings.Join(l.strings, "/")
+}
@@ -0,0 +1,244 @@
+package stdversion
+
+import (
+	"fmt"
+	"go/ast"
+	"go/types"
+	"sort"
+	"strconv"
+
+	"golang.org/x/tools/go/analysis"
+
+	"github.com/NonLogicalDev/gofancyimports/internal/stdlib"
+)
+
+const _name = "stdversion"
+const _doc = `This analysis pass reports standard library packages and symbols that are newer than
+the Go version of the module.
+
+The Go version is taken from the "go" directive of go.mod (or the -go flag), raised for files
+constrained to newer releases by their //go:build lines. Importing a package such as "iter" in
+a module declaring "go 1.21" silently bumps the minimum Go version required by its users.
+`
+
+var Analyzer = &analysis.Analyzer{
+	Name: _name,
+	Doc:  _doc,
+	Run:  run,
+
+	RunDespiteErrors: true,
+}
+
+var (
+	argGoVersion string
+)
+
+func init() {
+	Analyzer.Flags.StringVar(&argGoVersion,
+		"go", "",
+		"Go version to check against (e.g. 1.21), defaults to the go directive of go.mod")
+}
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	moduleVersion, ok := goVersion(pass)
+	if !ok {
+		return nil, nil
+	}
+
+	for _, file := range pass.Files {
+		version := moduleVersion
+		if fileVersion, ok := stdlib.ParseGoVersion(file.GoVersion); ok && fileVersion > version {
+			version = fileVersion
+		}
+
+		tooNew := reportNewerPackages(pass, file, version)
+		reportNewerSymbols(pass, file, version, tooNew)
+	}
+
+	return nil, nil
+}
+
+// goVersion returns the minor Go version the package is checked against.
+func goVersion(pass *analysis.Pass) (int, bool) {
+	if argGoVersion != "" {
+		return stdlib.ParseGoVersion(argGoVersion)
+	}
+	if pass.Module != nil && pass.Module.GoVersion != "" {
+		return stdlib.ParseGoVersion(pass.Module.GoVersion)
+	}
+	if pass.Pkg != nil {
+		return stdlib.ParseGoVersion(pass.Pkg.GoVersion())
+	}
+	return 0, false
+}
+
+// reportNewerPackages reports imports of standard library packages newer than the version,
+// returning their paths.
+func reportNewerPackages(pass *analysis.Pass, file *ast.File, version int) map[string]bool {
+	tooNew := map[string]bool{}
+	for _, spec := range file.Imports {
+		specPath, _ := strconv.Unquote(spec.Path.Value)
+		if !stdlib.IsStdlib(specPath) {
+			continue
+		}
+		introduced, ok := stdlib.PackageVersion(specPath)
+		if !ok || introduced <= version {
+			continue
+		}
+		tooNew[specPath] = true
+
+		pass.Report(analysis.Diagnostic{
+			Pos: spec.Pos(),
+			End: spec.End(),
+
+			Category: "stdversion",
+			Message:  fmt.Sprintf("package %q requires go1.%d, newer than go1.%d targeted by the file", specPath, introduced, version),
+		})
+	}
+	return tooNew
+}
+
+// reportNewerSymbols reports the first use in the file of every standard library symbol newer
+// than the version, skipping symbols of packages already reported as too new.
+func reportNewerSymbols(pass *analysis.Pass, file *ast.File, version int, tooNew map[string]bool) {
+	if pass.TypesInfo == nil {
+		return
+	}
+
+	var uses []*ast.Ident
+	for ident := range pass.TypesInfo.Uses {
+		if ident.Pos() >= file.Pos() && ident.End() <= file.End() {
+			uses = append(uses, ident)
+		}
+	}
+	sort.Slice(uses, func(i, j int) bool {
+		return uses[i].Pos() < uses[j].Pos()
+	})
+
+	owners := fieldOwners(pass.TypesInfo, file)
+	reported := map[string]bool{}
+	for _, ident := range uses {
+		obj := pass.TypesInfo.Uses[ident]
+		if obj.Pkg() == nil || obj.Pkg() == pass.Pkg {
+			continue
+		}
+		pkgPath := obj.Pkg().Path()
+		if tooNew[pkgPath] || !stdlib.IsStdlib(pkgPath) {
+			continue
+		}
+
+		name, ok := symbolName(obj, owners[ident])
+		if !ok || reported[pkgPath+"."+name] {
+			continue
+		}
+		introduced, ok := stdlib.SymbolVersion(pkgPath, name)
+		if !ok || introduced <= version {
+			continue
+		}
+		reported[pkgPath+"."+name] = true
+
+		pass.Report(analysis.Diagnostic{
+			Pos: ident.Pos(),
+			End: ident.End(),
+
+			Category: "stdversion",
+			Message:  fmt.Sprintf("%s.%s requires go1.%d, newer than go1.%d targeted by the file", pkgPath, name, introduced, version),
+		})
+	}
+}
+
+// symbolName returns the name of the object as it appears in the standard library symbol
+// table: package level symbols by name, methods qualified by their receiver and struct fields
+// qualified by the type declaring them (owner, empty when unknown).
+func symbolName(obj types.Object, owner string) (string, bool) {
+	if obj.Parent() == obj.Pkg().Scope() {
+		return obj.Name(), true
+	}
+	if v, ok := obj.(*types.Var); ok && v.IsField() {
+		if owner == "" {
+			return "", false
+		}
+		return owner + "." + v.Name(), true
+	}
+
+	fn, ok := obj.(*types.Func)
+	if !ok {
+		return "", false
+	}
+	recv := fn.Type().(*types.Signature).Recv()
+	if recv == nil {
+		return "", false
+	}
+
+	recvType, ptr := recv.Type(), ""
+	if p, ok := recvType.(*types.Pointer); ok {
+		recvType, ptr = p.Elem(), "*"
+	}
+	named, ok := recvType.(*types.Named)
+	if !ok {
+		return "", false
+	}
+	return fmt.Sprintf("(%s%s).%s", ptr, named.Obj().Name(), fn.Name()), true
+}
+
+// fieldOwners maps identifiers of struct fields used in the file, as selectors and as keys of
+// composite literals, to the name of the named struct type declaring the field.
+func fieldOwners(info *types.Info, file *ast.File) map[*ast.Ident]string {
+	owners := map[*ast.Ident]string{}
+	ast.Inspect(file, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.SelectorExpr:
+			sel, ok := info.Selections[n]
+			if !ok || sel.Kind() != types.FieldVal {
+				return true
+			}
+			if owner, ok := fieldOwner(sel.Recv(), sel.Index()); ok {
+				owners[n.Sel] = owner
+			}
+		case *ast.CompositeLit:
+			tv, ok := info.Types[n]
+			if !ok {
+				return true
+			}
+			named, ok := types.Unalias(tv.Type).(*types.Named)
+			if !ok {
+				return true
+			}
+			if _, ok := named.Underlying().(*types.Struct); !ok {
+				return true
+			}
+			for _, elt := range n.Elts {
+				if kv, ok := elt.(*ast.KeyValueExpr); ok {
+					if key, ok := kv.Key.(*ast.Ident); ok {
+						owners[key] = named.Obj().Name()
+					}
+				}
+			}
+		}
+		return true
+	})
+	return owners
+}
+
+// fieldOwner follows the path of embedded fields of a field selection, returning the name of
+// the named struct type declaring the selected field.
+func fieldOwner(recv types.Type, index []int) (string, bool) {
+	var owner string
+	typ := recv
+	for _, i := range index {
+		typ = types.Unalias(typ)
+		if ptr, ok := typ.(*types.Pointer); ok {
+			typ = types.Unalias(ptr.Elem())
+		}
+		st, ok := typ.Underlying().(*types.Struct)
+		if !ok {
+			return "", false
+		}
+		owner = ""
+		if named, ok := typ.(*types.Named); ok {
+			owner = named.Obj().Name()
+		}
+		typ = st.Field(i).Type()
+	}
+	return owner, owner != ""
+}
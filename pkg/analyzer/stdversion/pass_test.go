@@ -0,0 +1,18 @@
+package stdversion_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/tools/go/analysis/analysistest"
+
+	"github.com/NonLogicalDev/gofancyimports/pkg/analyzer/stdversion"
+)
+
+func TestModuleVersion(t *testing.T) {
+	analysistest.Run(t, filepath.Join(analysistest.TestData(), "mod"), stdversion.Analyzer, "./versions")
+}
+
+func TestWithoutModule(t *testing.T) {
+	analysistest.Run(t, analysistest.TestData(), stdversion.Analyzer, "nomod")
+}
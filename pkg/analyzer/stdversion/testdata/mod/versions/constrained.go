@@ -0,0 +1,9 @@
+//go:build go1.23
+
+package versions
+
+import "slices"
+
+func constrained(xs []int) []int {
+	return slices.Repeat(xs, 2)
+}
@@ -0,0 +1,7 @@
+package versions
+
+import "net/http"
+
+func literal() *http.Request {
+	return &http.Request{Pattern: "/"} // want `net/http.Request.Pattern requires go1.23, newer than go1.21`
+}
@@ -0,0 +1,33 @@
+package versions
+
+import (
+	"iter" // want `package "iter" requires go1.23, newer than go1.21 targeted by the file`
+	"net/http"
+	"slices"
+	"strings"
+)
+
+func below(s string) (string, string, bool) {
+	return strings.Cut(s, ",")
+}
+
+func at(xs []int) bool {
+	return slices.Contains(xs, 1)
+}
+
+func above(xs []int) []int {
+	return slices.Repeat(xs, 2) // want `slices.Repeat requires go1.23, newer than go1.21 targeted by the file`
+}
+
+func method(r *http.Request) string {
+	return r.PathValue("id") // want `net/http.\(\*Request\).PathValue requires go1.22, newer than go1.21`
+}
+
+func field(r *http.Request) string {
+	return r.Pattern // want `net/http.Request.Pattern requires go1.23, newer than go1.21`
+}
+
+func sequence(seq iter.Seq[int]) {
+	for range seq {
+	}
+}
@@ -0,0 +1,7 @@
+package nomod
+
+import "slices"
+
+func repeat(xs []int) []int {
+	return slices.Repeat(xs, 2)
+}
@@ -0,0 +1,195 @@
+package autogroup
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"sort"
+	"strconv"
+
+	"github.com/NonLogicalDev/gofancyimports/internal/astutils"
+	"github.com/NonLogicalDev/gofancyimports/pkg/types"
+)
+
+// WithImportAliases sets the aliases of imports of the paths present in the aliases table
+// (mapping import paths to aliases), e.g. importing `k8s.io/api/core/v1` as `corev1`, or
+// removes them when the alias is the package name. With [WithFile], references to the
+// aliased packages in the file are renamed as well.
+//
+// Imports whose alias is not usable in the file (see [CheckImportAliases]), side effect and
+// dot imports, and imports in declarations marked with the keep directive are left untouched.
+func WithImportAliases(aliases map[string]string) Option {
+	return func(conf *config) {
+		if conf.importAliases == nil {
+			conf.importAliases = map[string]string{}
+		}
+		for importPath, alias := range aliases {
+			conf.importAliases[importPath] = alias
+		}
+	}
+}
+
+// NewAliasTransform returns a transform setting import aliases as [WithImportAliases] does,
+// without organizing imports otherwise: declarations, groups and the order of imports are
+// left as they are. Options other than [WithFile] and [WithImportAliases] have no effect.
+func NewAliasTransform(opts ...Option) types.ImportTransform {
+	org := organizer{}
+	for _, apply := range opts {
+		apply(&org.config)
+	}
+	return func(decls []types.ImportDeclaration) []types.ImportDeclaration {
+		decls, renames := org.setImportAliases(decls)
+		if len(decls) > 0 && renames != nil {
+			decls[0].IdentRenames = mergeIdentRenames(decls[0].IdentRenames, renames)
+		}
+		return decls
+	}
+}
+
+// CheckImportAliases returns an error when imports of the paths in the file can not be given
+// the aliases from the aliases table: the alias is not a valid identifier, is declared in the
+// file (in any scope, where it would shadow the import) or is the name of another import.
+func CheckImportAliases(file *ast.File, aliases map[string]string) error {
+	org := organizer{}
+	return org.checkImportAliases(file.Imports, file, aliases)
+}
+
+// checkImportAliases checks the aliases against the imports, and against the identifiers
+// declared in the file when it is not nil.
+func (org *organizer) checkImportAliases(specs []*ast.ImportSpec, file *ast.File, aliases map[string]string) error {
+	imported := map[string]bool{}
+	for _, s := range specs {
+		specPath, _ := strconv.Unquote(s.Path.Value)
+		imported[specPath] = true
+	}
+
+	paths := make([]string, 0, len(aliases))
+	for importPath := range aliases {
+		if imported[importPath] {
+			paths = append(paths, importPath)
+		}
+	}
+	if len(paths) == 0 {
+		return nil
+	}
+	sort.Strings(paths)
+
+	declared := map[string]bool{}
+	if file != nil {
+		for _, name := range astutils.DeclaredNames(file) {
+			declared[name] = true
+		}
+		for _, name := range astutils.LocalNames(file) {
+			declared[name] = true
+		}
+	}
+
+	for _, importPath := range paths {
+		alias := aliases[importPath]
+		if !token.IsIdentifier(alias) || alias == "_" {
+			return fmt.Errorf("invalid alias %q for import %q", alias, importPath)
+		}
+		if declared[alias] {
+			return fmt.Errorf("alias %s for import %q collides with an identifier declared in the file", alias, importPath)
+		}
+		for _, s := range specs {
+			specPath, _ := strconv.Unquote(s.Path.Value)
+			if specPath == importPath || org.importName(s) != alias {
+				continue
+			}
+			if other, ok := aliases[specPath]; ok && other != alias {
+				continue
+			}
+			return fmt.Errorf("alias %s for import %q collides with import %q", alias, importPath, specPath)
+		}
+	}
+	return nil
+}
+
+// setImportAliases aliases imports present in the aliases table, returning renames of
+// references to them. No alias is set unless all of them are usable in the file.
+func (org *organizer) setImportAliases(decls []types.ImportDeclaration) ([]types.ImportDeclaration, map[*ast.Ident]string) {
+	if len(org.config.importAliases) == 0 {
+		return decls, nil
+	}
+
+	var specs []*ast.ImportSpec
+	for _, d := range decls {
+		for _, g := range d.ImportGroups {
+			specs = append(specs, g.Specs...)
+		}
+	}
+	if org.checkImportAliases(specs, org.config.file, org.config.importAliases) != nil {
+		return decls, nil
+	}
+
+	var selectors []*ast.SelectorExpr
+	if org.config.file != nil {
+		selectors = astutils.PackageSelectors(org.config.file)
+	}
+	qualifiers := map[string]bool{}
+	for _, sel := range selectors {
+		qualifiers[sel.X.(*ast.Ident).Name] = true
+	}
+
+	// renamed maps names of aliased imports to their aliases.
+	renamed := map[string]string{}
+	for _, d := range decls {
+		if d.Keep {
+			continue
+		}
+		for _, g := range d.ImportGroups {
+			for _, s := range g.Specs {
+				specPath, _ := strconv.Unquote(s.Path.Value)
+				alias, ok := org.config.importAliases[specPath]
+				if !ok {
+					continue
+				}
+				name := org.referencedName(s, qualifiers)
+				if name == "_" || name == "." || name == alias {
+					continue
+				}
+				renamed[name] = alias
+				if org.importName(&ast.ImportSpec{Path: s.Path}) == alias {
+					s.Name = nil
+				} else {
+					s.Name = &ast.Ident{NamePos: s.Pos(), Name: alias}
+				}
+			}
+		}
+	}
+	if len(renamed) == 0 || org.config.file == nil {
+		return decls, nil
+	}
+
+	renames := map[*ast.Ident]string{}
+	for _, sel := range selectors {
+		x := sel.X.(*ast.Ident)
+		if alias, ok := renamed[x.Name]; ok {
+			renames[x] = alias
+		}
+	}
+	return decls, renames
+}
+
+// ImportNames returns the names under which the file imports the paths, excluding side effect
+// and dot imports. Names of packages named after a major version suffix are resolved by the
+// qualifiers referenced in the file.
+func ImportNames(file *ast.File) map[string]string {
+	qualifiers := map[string]bool{}
+	for _, sel := range astutils.PackageSelectors(file) {
+		qualifiers[sel.X.(*ast.Ident).Name] = true
+	}
+
+	org := organizer{}
+	names := map[string]string{}
+	for _, s := range file.Imports {
+		name := org.referencedName(s, qualifiers)
+		specPath, _ := strconv.Unquote(s.Path.Value)
+		if _, ok := names[specPath]; ok || name == "_" || name == "." || s.Path.Value == cgoImportPath {
+			continue
+		}
+		names[specPath] = name
+	}
+	return names
+}
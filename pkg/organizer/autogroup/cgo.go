@@ -0,0 +1,63 @@
+package autogroup
+
+import (
+	"go/ast"
+
+	"github.com/NonLogicalDev/gofancyimports/pkg/types"
+)
+
+// cgoImportPath is the quoted path of the pseudo package enabling cgo.
+const cgoImportPath = `"C"`
+
+// splitCgoImports separates imports of "C" from the rest of the declarations. Every import of
+// "C" ends up in a declaration of its own, headed by its preamble, in the order of the source.
+//
+// The preamble of an import of "C" is the doc comment of its group when the import heads the
+// group, or the doc comment of the declaration when it is the only import of the declaration,
+// matching what cgo itself considers to be the preamble. Declarations marked with the keep
+// directive are left untouched unless they only import "C".
+func splitCgoImports(decls []types.ImportDeclaration) (cgoDecls, rest []types.ImportDeclaration) {
+	for _, d := range decls {
+		if isCgoDecl(d) {
+			cgoDecls = append(cgoDecls, d)
+			continue
+		}
+		if d.Keep {
+			rest = append(rest, d)
+			continue
+		}
+
+		var groups []types.ImportGroup
+		for _, g := range d.ImportGroups {
+			var specs []*ast.ImportSpec
+			for specIdx, s := range g.Specs {
+				if s.Path.Value != cgoImportPath {
+					specs = append(specs, s)
+					continue
+				}
+
+				cgoDecl := types.ImportDeclaration{
+					ImportGroups: []types.ImportGroup{{Specs: []*ast.ImportSpec{s}}},
+				}
+				if specIdx == 0 {
+					cgoDecl.Doc, g.Doc = g.Doc, nil
+				}
+				cgoDecls = append(cgoDecls, cgoDecl)
+			}
+			g.Specs = specs
+			groups = append(groups, g)
+		}
+		d.ImportGroups = groups
+		rest = append(rest, d)
+	}
+	return cgoDecls, rest
+}
+
+// isCgoDecl reports whether the declaration consists of a single import of "C".
+func isCgoDecl(d types.ImportDeclaration) bool {
+	var specs []*ast.ImportSpec
+	for _, g := range d.ImportGroups {
+		specs = append(specs, g.Specs...)
+	}
+	return len(specs) == 1 && specs[0].Path.Value == cgoImportPath
+}
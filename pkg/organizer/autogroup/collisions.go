@@ -0,0 +1,322 @@
+package autogroup
+
+import (
+	"go/ast"
+	"go/token"
+	astTypes "go/types"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/NonLogicalDev/gofancyimports/internal/astutils"
+	"github.com/NonLogicalDev/gofancyimports/internal/stdlib"
+	"github.com/NonLogicalDev/gofancyimports/pkg/types"
+)
+
+// AliasScheme derives an alias for an import whose package name collides with the name of
+// another import. An empty result falls back to numbered aliases (`rand2`).
+type AliasScheme func(importPath string, name string) string
+
+// AliasParentInitial prefixes the package name with the initial of its parent directory
+// (`crypto/rand` -> `crand`, `math/rand` -> `mrand`).
+func AliasParentInitial(importPath string, name string) string {
+	parent := parentDirName(importPath, name)
+	if parent == "" {
+		return ""
+	}
+	return parent[:1] + name
+}
+
+// AliasParentDir prefixes the package name with the name of its parent directory
+// (`k8s.io/api/core/v1` -> `corev1`, `k8s.io/api/apps/v1` -> `appsv1`).
+func AliasParentDir(importPath string, name string) string {
+	parent := parentDirName(importPath, name)
+	if parent == "" {
+		return ""
+	}
+	return parent + name
+}
+
+// Alias returns the alias the scheme derives for an import of the package name, numbered to
+// avoid names that are already taken. The package name is used instead when the scheme is nil
+// or derives no usable alias.
+func (scheme AliasScheme) Alias(importPath string, name string, taken map[string]bool) string {
+	var alias string
+	if scheme != nil {
+		alias = scheme(importPath, name)
+	}
+	return uniqueAlias(alias, name, taken)
+}
+
+// ParseAliasScheme parses scheme name (e.g. from command line flags): `off` (or empty
+// string) disabling collision resolution, `initial` or `parent`.
+func ParseAliasScheme(name string) (AliasScheme, bool) {
+	switch name {
+	case "", "off":
+		return nil, true
+	case "initial":
+		return AliasParentInitial, true
+	case "parent":
+		return AliasParentDir, true
+	default:
+		return nil, false
+	}
+}
+
+// WithCollisionAliases enables resolution of import name collisions (e.g. `crypto/rand` and
+// `math/rand` imported without aliases), which are otherwise a compile error. All colliding
+// imports but one get an alias derived by the scheme, preferring to keep the name of
+// explicitly aliased imports, then of standard library imports, then of the shortest path.
+//
+// With [WithFile], references to the aliased packages in the file are renamed as well.
+// References are attributed to packages by the symbols they select, collisions with references
+// that can not be attributed to a single import are left unresolved.
+func WithCollisionAliases(scheme AliasScheme) Option {
+	return func(conf *config) {
+		conf.collisionAliases = scheme
+	}
+}
+
+// WithPackageTypes provides type information of imported packages, used to resolve package
+// names and exported symbols instead of assuming them from import paths.
+func WithPackageTypes(pkgTypeInfo map[string]*astTypes.Package) Option {
+	return func(conf *config) {
+		conf.pkgTypeInfo = pkgTypeInfo
+	}
+}
+
+// ImportNameCollisions returns imports that are available in the file under the same name
+// while importing different paths, keyed by that name. Package names are resolved using
+// pkgTypeInfo when available (it may be nil) and assumed from import paths otherwise.
+func ImportNameCollisions(specs []*ast.ImportSpec, pkgTypeInfo map[string]*astTypes.Package) map[string][]*ast.ImportSpec {
+	org := organizer{config: config{pkgTypeInfo: pkgTypeInfo}}
+	return org.nameCollisions(specs, nil)
+}
+
+// nameCollisions groups colliding imports by name. Without type information, names of packages
+// named after a major version suffix are resolved by the qualifiers referenced in the file.
+func (org *organizer) nameCollisions(specs []*ast.ImportSpec, qualifiers map[string]bool) map[string][]*ast.ImportSpec {
+	byName := map[string][]*ast.ImportSpec{}
+	seen := map[string]bool{}
+	for _, s := range specs {
+		name := org.referencedName(s, qualifiers)
+		if name == "_" || name == "." || s.Path.Value == cgoImportPath {
+			continue
+		}
+		if key := name + " " + s.Path.Value; !seen[key] {
+			seen[key] = true
+			byName[name] = append(byName[name], s)
+		}
+	}
+
+	collisions := map[string][]*ast.ImportSpec{}
+	for name, specs := range byName {
+		if len(specs) > 1 {
+			collisions[name] = specs
+		}
+	}
+	return collisions
+}
+
+// resolveNameCollisions aliases colliding imports, returning renames of references to them.
+// Imports in declarations marked with the keep directive are never aliased.
+func (org *organizer) resolveNameCollisions(decls []types.ImportDeclaration) map[*ast.Ident]string {
+	if org.config.collisionAliases == nil {
+		return nil
+	}
+
+	var (
+		specs []*ast.ImportSpec
+		kept  = map[*ast.ImportSpec]bool{}
+	)
+	for _, d := range decls {
+		for _, g := range d.ImportGroups {
+			for _, s := range g.Specs {
+				specs = append(specs, s)
+				kept[s] = d.Keep
+			}
+		}
+	}
+
+	var selectors []*ast.SelectorExpr
+	if org.config.file != nil {
+		selectors = astutils.PackageSelectors(org.config.file)
+	}
+	qualifiers := map[string]bool{}
+	for _, sel := range selectors {
+		qualifiers[sel.X.(*ast.Ident).Name] = true
+	}
+
+	collisions := org.nameCollisions(specs, qualifiers)
+	if len(collisions) == 0 {
+		return nil
+	}
+
+	taken := map[string]bool{}
+	for _, s := range specs {
+		taken[org.referencedName(s, qualifiers)] = true
+	}
+	if org.config.file != nil {
+		for _, name := range astutils.DeclaredNames(org.config.file) {
+			taken[name] = true
+		}
+	}
+
+	names := make([]string, 0, len(collisions))
+	for name := range collisions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	renames := map[*ast.Ident]string{}
+	for _, name := range names {
+		colliding := collisions[name]
+		sort.SliceStable(colliding, func(i, j int) bool {
+			return org.keepsNameBefore(colliding[i], colliding[j], kept)
+		})
+
+		// Aliasing an import without renaming all of its references breaks the file, so
+		// the collision is left alone unless every reference has a provable owner.
+		owners := map[*ast.Ident]*ast.ImportSpec{}
+		provable := true
+		for _, sel := range selectors {
+			if x := sel.X.(*ast.Ident); x.Name == name {
+				owners[x] = org.selectorOwner(colliding, sel)
+				provable = provable && owners[x] != nil
+			}
+		}
+		if !provable {
+			continue
+		}
+
+		for _, s := range colliding[1:] {
+			if kept[s] {
+				continue
+			}
+			specPath, _ := strconv.Unquote(s.Path.Value)
+			alias := org.config.collisionAliases.Alias(specPath, name, taken)
+			taken[alias] = true
+			s.Name = &ast.Ident{
+				NamePos: s.Pos(),
+				Name:    alias,
+			}
+
+			for x, owner := range owners {
+				if owner == s {
+					renames[x] = alias
+				}
+			}
+		}
+	}
+	return renames
+}
+
+// keepsNameBefore orders colliding imports by their claim to keep the name.
+func (org *organizer) keepsNameBefore(a, b *ast.ImportSpec, kept map[*ast.ImportSpec]bool) bool {
+	if kept[a] != kept[b] {
+		return kept[a]
+	}
+	if (a.Name != nil) != (b.Name != nil) {
+		return a.Name != nil
+	}
+	aPath, _ := strconv.Unquote(a.Path.Value)
+	bPath, _ := strconv.Unquote(b.Path.Value)
+	if aStd, bStd := stdlib.IsStdlib(aPath), stdlib.IsStdlib(bPath); aStd != bStd {
+		return aStd
+	}
+	if len(aPath) != len(bPath) {
+		return len(aPath) < len(bPath)
+	}
+	return aPath < bPath
+}
+
+// selectorOwner returns the colliding import the reference refers to, the only one possibly
+// exporting the selected symbol, or nil when it can not be told.
+func (org *organizer) selectorOwner(colliding []*ast.ImportSpec, sel *ast.SelectorExpr) *ast.ImportSpec {
+	var candidates []*ast.ImportSpec
+	for _, s := range colliding {
+		if org.mayExport(s, sel.Sel.Name) {
+			candidates = append(candidates, s)
+		}
+	}
+	if len(candidates) == 1 {
+		return candidates[0]
+	}
+	return nil
+}
+
+// mayExport reports whether the imported package may export the symbol, which is always
+// the case for packages without type information outside of the standard library.
+func (org *organizer) mayExport(s *ast.ImportSpec, symbol string) bool {
+	specPath, _ := strconv.Unquote(s.Path.Value)
+	if pkg, ok := org.config.pkgTypeInfo[specPath]; ok && pkg.Complete() {
+		return pkg.Scope().Lookup(symbol) != nil
+	}
+	if stdlib.IsStdlib(specPath) {
+		return stdlib.HasSymbol(specPath, symbol)
+	}
+	return true
+}
+
+// importName returns the name under which the import is available in the file, preferring
+// the package name from type information.
+func (org *organizer) importName(s *ast.ImportSpec) string {
+	if s.Name != nil {
+		return s.Name.Name
+	}
+	specPath, _ := strconv.Unquote(s.Path.Value)
+	if pkg, ok := org.config.pkgTypeInfo[specPath]; ok {
+		return pkg.Name()
+	}
+	return assumedPackageName(specPath)
+}
+
+// referencedName returns the name of the import, resolving the ambiguity of packages named
+// after a major version suffix (`k8s.io/api/core/v1` is package `v1`) by the qualifiers
+// referenced in the file when the package name is neither explicit nor known.
+func (org *organizer) referencedName(s *ast.ImportSpec, qualifiers map[string]bool) string {
+	name := org.importName(s)
+	specPath, _ := strconv.Unquote(s.Path.Value)
+	if _, known := org.config.pkgTypeInfo[specPath]; s.Name != nil || known || qualifiers[name] {
+		return name
+	}
+	if base := path.Base(specPath); stdlib.IsMajorVersionSuffix(base) && qualifiers[base] {
+		return base
+	}
+	return name
+}
+
+// uniqueAlias returns the alias, or the name when the alias is not usable, numbered to
+// avoid names that are already taken.
+func uniqueAlias(alias string, name string, taken map[string]bool) string {
+	if !token.IsIdentifier(alias) {
+		alias = name
+	}
+	candidate := alias
+	for i := 2; taken[candidate]; i++ {
+		candidate = alias + strconv.Itoa(i)
+	}
+	return candidate
+}
+
+// parentDirName returns the name of the directory containing the package, reduced to
+// lower case letters and digits. Major version suffixes that are not the package name
+// are skipped (`github.com/go-redis/redis/v8` -> `goredis`).
+func parentDirName(importPath string, name string) string {
+	dir := importPath
+	if base := path.Base(dir); base != name && stdlib.IsMajorVersionSuffix(base) {
+		dir = path.Dir(dir)
+	}
+	parent := path.Dir(dir)
+	if parent == "." || parent == "/" {
+		return ""
+	}
+	return strings.Map(func(r rune) rune {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, path.Base(parent))
+}
@@ -0,0 +1,198 @@
+package autogroup
+
+import (
+	"go/ast"
+	"go/token"
+	"sort"
+
+	"github.com/NonLogicalDev/gofancyimports/pkg/types"
+)
+
+// CommentPlacement controls where comments floating between imports (i.e. comments that are
+// separated from imports by blank lines) are placed.
+type CommentPlacement int
+
+const (
+	// CommentsHoist moves all floating comments above the first import declaration. This is
+	// the default.
+	CommentsHoist CommentPlacement = iota
+	// CommentsKeepInside keeps floating comments inside the import declaration, directly above
+	// the import that followed them in the source. The group containing the import is split
+	// before it unless the import comes first in the group.
+	CommentsKeepInside
+	// CommentsAttach attaches floating comments to the import that followed them in the source
+	// as its doc comment. Since doc commented imports form sticky groups, the import is kept in
+	// a group of its own, headed by the comment.
+	CommentsAttach
+)
+
+// ParseCommentPlacement parses placement name (e.g. from command line flags): `hoist` (or
+// empty string), `inside` or `attach`.
+func ParseCommentPlacement(name string) (CommentPlacement, bool) {
+	switch name {
+	case "", "hoist":
+		return CommentsHoist, true
+	case "inside":
+		return CommentsKeepInside, true
+	case "attach":
+		return CommentsAttach, true
+	default:
+		return CommentsHoist, false
+	}
+}
+
+// WithCommentPlacement controls where comments floating between imports are placed.
+// Comments that were not followed by any import (e.g. trailing comments of a declaration)
+// are always hoisted.
+func WithCommentPlacement(placement CommentPlacement) Option {
+	return func(conf *config) {
+		conf.commentPlacement = placement
+	}
+}
+
+// WithDocCommentDedup enables removal of repeated comments from doc comments of declarations
+// and groups, which commonly appear after merging blocks carrying the same directive (e.g.
+// two blocks both documented with `// nolint:depguard`). Only comments repeated across the
+// merged doc comments are removed, doc comments are otherwise left as they are.
+//
+// Requires [WithFile].
+func WithDocCommentDedup(enable bool) Option {
+	return func(conf *config) {
+		conf.dedupDocComments = enable
+	}
+}
+
+// dedupDocComments removes repeated comments from doc comments of the declaration and its
+// groups (see [types.DedupDocComments]).
+func (org *organizer) dedupDocComments(d *types.ImportDeclaration) {
+	if org.config.fset == nil {
+		return
+	}
+	lineOf := func(pos token.Pos) int {
+		return org.config.fset.Position(pos).Line
+	}
+
+	d.Doc = types.DedupDocComments(d.Doc, lineOf)
+	for i := range d.ImportGroups {
+		d.ImportGroups[i].Doc = types.DedupDocComments(d.ImportGroups[i].Doc, lineOf)
+	}
+}
+
+// anchorComments takes anchored floating comments out of the declarations, returning them
+// keyed by the spec that followed them in the source.
+func (org *organizer) anchorComments(decls []types.ImportDeclaration) map[*ast.ImportSpec][]*ast.CommentGroup {
+	if org.config.commentPlacement == CommentsHoist {
+		return nil
+	}
+
+	anchored := map[*ast.ImportSpec][]*ast.CommentGroup{}
+	takeAnchored := func(d types.ImportDeclaration, comments []*ast.CommentGroup) []*ast.CommentGroup {
+		var rest []*ast.CommentGroup
+		for _, cg := range comments {
+			if spec := d.CommentAnchors[cg]; spec != nil {
+				anchored[spec] = append(anchored[spec], cg)
+			} else {
+				rest = append(rest, cg)
+			}
+		}
+		return rest
+	}
+	for i := range decls {
+		decls[i].LeadingComments = takeAnchored(decls[i], decls[i].LeadingComments)
+		decls[i].DetachedComments = takeAnchored(decls[i], decls[i].DetachedComments)
+		decls[i].CommentAnchors = nil
+	}
+	return anchored
+}
+
+// attachAnchoredComments moves specs with anchored comments into groups of their own headed
+// by the comments. Declarations marked with the keep directive are left untouched.
+func (org *organizer) attachAnchoredComments(decls []types.ImportDeclaration, anchored map[*ast.ImportSpec][]*ast.CommentGroup) {
+	if org.config.commentPlacement != CommentsAttach {
+		return
+	}
+
+	for declIdx := range decls {
+		d := &decls[declIdx]
+		if d.Keep {
+			continue
+		}
+
+		var groups []types.ImportGroup
+		for _, g := range d.ImportGroups {
+			var (
+				specs    []*ast.ImportSpec
+				attached []types.ImportGroup
+			)
+			for specIdx, s := range g.Specs {
+				comments, ok := anchored[s]
+				if !ok {
+					specs = append(specs, s)
+					continue
+				}
+				delete(anchored, s)
+
+				// A spec heading a sticky group keeps its group, the comment joins the header.
+				if specIdx == 0 && g.Doc != nil {
+					g.Doc = types.MergeDocComments(append(comments, g.Doc))
+					specs = append(specs, s)
+					continue
+				}
+				attached = append(attached, types.ImportGroup{
+					Doc:   types.MergeDocComments(comments),
+					Specs: []*ast.ImportSpec{s},
+				})
+			}
+			g.Specs = specs
+			groups = append(groups, g)
+			groups = append(groups, attached...)
+		}
+		d.ImportGroups = groups
+	}
+}
+
+// placeAnchoredComments places anchored comments directly above the specs that followed them
+// in the source, splitting groups before specs that are not the first of their group, and
+// returns comments whose specs are no longer present.
+func placeAnchoredComments(decls []types.ImportDeclaration, anchored map[*ast.ImportSpec][]*ast.CommentGroup) []*ast.CommentGroup {
+	for declIdx := range decls {
+		var groups []types.ImportGroup
+		for _, g := range decls[declIdx].ImportGroups {
+			specs := g.Specs
+			start := 0
+			for specIdx, s := range specs {
+				comments, ok := anchored[s]
+				if !ok {
+					continue
+				}
+				delete(anchored, s)
+
+				if specIdx == 0 {
+					g.LeadingComments = append(g.LeadingComments, comments...)
+					continue
+				}
+				g.Specs = specs[start:specIdx]
+				groups = append(groups, g)
+
+				g = types.ImportGroup{
+					LeadingComments: comments,
+					NoSort:          g.NoSort,
+					SpecGroups:      g.SpecGroups,
+				}
+				start = specIdx
+			}
+			g.Specs = specs[start:]
+			groups = append(groups, g)
+		}
+		decls[declIdx].ImportGroups = groups
+	}
+
+	var orphaned []*ast.CommentGroup
+	for _, comments := range anchored {
+		orphaned = append(orphaned, comments...)
+	}
+	sort.Slice(orphaned, func(i, j int) bool {
+		return orphaned[i].Pos() < orphaned[j].Pos()
+	})
+	return orphaned
+}
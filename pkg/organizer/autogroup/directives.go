@@ -0,0 +1,122 @@
+package autogroup
+
+import (
+	"go/ast"
+	"strconv"
+	"strings"
+
+	"github.com/NonLogicalDev/gofancyimports/internal/astutils"
+	"github.com/NonLogicalDev/gofancyimports/pkg/types"
+)
+
+// directiveImports maps compiler directives to packages that have to be imported
+// in files using them.
+var directiveImports = []struct {
+	directive string
+	path      string
+}{
+	{directive: "//go:embed", path: "embed"},
+	{directive: "//go:linkname", path: "unsafe"},
+}
+
+// WithDirectiveImports enables management of imports required by compiler directives
+// found in the file: `//go:embed` requires package `embed` and `//go:linkname` requires
+// package `unsafe`.
+//
+// When a directive is present and the package is not imported, a side effect import is
+// added with a justification comment. When the directive is gone, the side effect import
+// is removed. When the package is referenced in the file (e.g. `embed.FS`), a side effect
+// import is converted to a regular import, and vice versa.
+//
+// Requires [WithFile].
+func WithDirectiveImports(enable bool) Option {
+	return func(conf *config) {
+		conf.directiveImports = enable
+	}
+}
+
+func (org *organizer) fixupDirectiveImports(decls []types.ImportDeclaration) []types.ImportDeclaration {
+	if org.config.file == nil || !org.config.directiveImports {
+		return decls
+	}
+
+	refs := astutils.UnresolvedSelectors(org.config.file)
+	for _, d := range directiveImports {
+		var (
+			required   = hasDirective(org.config.file, d.directive)
+			referenced = len(refs[assumedPackageName(d.path)]) > 0
+			imported   = false
+		)
+
+		for declIdx := range decls {
+			if decls[declIdx].Keep {
+				imported = imported || declImports(decls[declIdx], d.path)
+				continue
+			}
+			for groupIdx := range decls[declIdx].ImportGroups {
+				group := &decls[declIdx].ImportGroups[groupIdx]
+
+				var specs []*ast.ImportSpec
+				for _, s := range group.Specs {
+					specPath, _ := strconv.Unquote(s.Path.Value)
+					if specPath != d.path {
+						specs = append(specs, s)
+						continue
+					}
+
+					isBlank := s.Name != nil && s.Name.Name == "_"
+					switch {
+					case isBlank && referenced:
+						s.Name = nil
+						if s.Comment != nil && s.Comment.Text() == DefaultBlankImportComments[d.path]+"\n" {
+							s.Comment = nil
+						}
+					case isBlank && !required:
+						continue
+					case s.Name == nil && !referenced && required:
+						s.Name = &ast.Ident{Name: "_"}
+						if s.Comment == nil {
+							s.Comment = makeLineComment(DefaultBlankImportComments[d.path])
+						}
+					}
+					imported = true
+					specs = append(specs, s)
+				}
+				group.Specs = specs
+			}
+		}
+
+		if required && !imported {
+			spec := makeImportSpec("_", d.path)
+			spec.Comment = makeLineComment(DefaultBlankImportComments[d.path])
+			decls = append(decls, types.ImportDeclaration{
+				ImportGroups: []types.ImportGroup{{Specs: []*ast.ImportSpec{spec}}},
+			})
+		}
+	}
+	return decls
+}
+
+// declImports reports whether the declaration contains an import of the path.
+func declImports(decl types.ImportDeclaration, importPath string) bool {
+	for _, g := range decl.ImportGroups {
+		for _, s := range g.Specs {
+			if specPath, _ := strconv.Unquote(s.Path.Value); specPath == importPath {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// hasDirective reports whether any of the file comments is the provided directive.
+func hasDirective(file *ast.File, directive string) bool {
+	for _, cg := range file.Comments {
+		for _, c := range cg.List {
+			if c.Text == directive || strings.HasPrefix(c.Text, directive+" ") || strings.HasPrefix(c.Text, directive+"\t") {
+				return true
+			}
+		}
+	}
+	return false
+}
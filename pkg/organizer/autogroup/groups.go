@@ -0,0 +1,397 @@
+package autogroup
+
+import (
+	"fmt"
+	"go/ast"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/NonLogicalDev/gofancyimports/pkg/types"
+)
+
+// GroupKind identifies one of the groups that autogroup sorts imports into.
+type GroupKind string
+
+const (
+	// GroupStdlib contains standard library imports.
+	GroupStdlib GroupKind = "std"
+	// GroupExtendedStdlib contains imports of packages maintained alongside the standard
+	// library (`golang.org/x/...`), see [WithExtendedStdlibGroup].
+	GroupExtendedStdlib GroupKind = "x"
+	// GroupNoDot contains imports without dots in the first path component, see [WithNoDotGroupEnabled].
+	GroupNoDot GroupKind = "nodot"
+	// GroupThirdParty contains all imports that do not belong to any other group.
+	GroupThirdParty GroupKind = "thirdparty"
+	// GroupLocal contains local imports, see [WithLocalPrefixGroup].
+	GroupLocal GroupKind = "local"
+	// GroupSideEffect contains side effect imports, see [WithSideEffectGroupEnabled].
+	GroupSideEffect GroupKind = "effect"
+	// GroupDot contains dot imports, see [WithDotImportGroup].
+	GroupDot GroupKind = "dot"
+	// GroupInternal contains imports of `internal` packages, see [WithInternalGroup].
+	GroupInternal GroupKind = "internal"
+	// GroupAlias contains explicitly aliased imports, see [WithAliasGroup].
+	GroupAlias GroupKind = "alias"
+	// GroupTestHelper contains imports of test helper packages in test files, see [WithTestHelperGroup].
+	GroupTestHelper GroupKind = "testhelper"
+	// GroupPackageUnderTest contains the import of the package under test, see [WithPackageUnderTestGroup].
+	// Its position is controlled by the placement rather than by the group order.
+	GroupPackageUnderTest GroupKind = "undertest"
+)
+
+// DefaultGroupOrder is the order in which groups are emitted unless overridden by [WithGroupOrder].
+var DefaultGroupOrder = []GroupKind{
+	GroupStdlib,
+	GroupExtendedStdlib,
+	GroupNoDot,
+	GroupThirdParty,
+	GroupTestHelper,
+	GroupLocal,
+	GroupInternal,
+	GroupSideEffect,
+	GroupDot,
+	GroupAlias,
+}
+
+// WithGroupOrder overrides the order in which groups are emitted. Groups that are not
+// listed are emitted after the listed ones in their default order.
+//
+// Example:
+//
+//	autogroup.WithGroupOrder(autogroup.GroupDot, autogroup.GroupStdlib)
+func WithGroupOrder(kinds ...GroupKind) Option {
+	return func(conf *config) {
+		conf.groupOrder = kinds
+	}
+}
+
+// WithDotImportGroup enables an extra group for dot imports (e.g. `. "github.com/onsi/gomega"`),
+// which are otherwise grouped by their path like any other import.
+func WithDotImportGroup(enable bool) Option {
+	return func(conf *config) {
+		conf.groupDotImports = enable
+	}
+}
+
+// ParseGroupOrder parses a list of group kind names (e.g. from command line flags) into a group
+// order for [WithGroupOrder], validating that all of them are known group kinds.
+func ParseGroupOrder(names []string) ([]GroupKind, error) {
+	known := map[GroupKind]bool{}
+	for _, kind := range DefaultGroupOrder {
+		known[kind] = true
+	}
+
+	var order []GroupKind
+	for _, name := range names {
+		kind := GroupKind(strings.TrimSpace(name))
+		if kind == "" {
+			continue
+		}
+		if !known[kind] {
+			return nil, fmt.Errorf("unknown group %q", name)
+		}
+		order = append(order, kind)
+	}
+	return order, nil
+}
+
+// WithAliasGroup enables an extra group for explicitly aliased imports (excluding side
+// effect and dot imports), mirroring the alias section of gci.
+//
+// An import only counts as aliased when its alias differs from the package name assumed
+// from its path, so aliases that merely restate the package name, like the ones added by
+// [FixupDefaultImportAlias] to version suffixed paths (`redis "github.com/go-redis/redis/v8"`),
+// do not move imports into the alias group.
+func WithAliasGroup(enable bool) Option {
+	return func(conf *config) {
+		conf.groupAliasImports = enable
+	}
+}
+
+// WithInternalGroup enables an extra group for imports of `internal` packages (i.e. paths
+// containing an `internal` element, like `github.com/acme/svc/internal/db`), separating
+// them from the rest of local and third party imports.
+func WithInternalGroup(enable bool) Option {
+	return func(conf *config) {
+		conf.groupInternalImports = enable
+	}
+}
+
+// WithInternalGroupModule restricts the internal group to internal packages of the module
+// with the provided path, internal packages of other modules are grouped as usual.
+func WithInternalGroupModule(modulePath string) Option {
+	return func(conf *config) {
+		conf.internalGroupModule = modulePath
+	}
+}
+
+// DefaultExtendedStdlibPrefixes contains import path prefixes of packages that are
+// maintained by the Go team outside of the standard library.
+var DefaultExtendedStdlibPrefixes = []string{
+	"golang.org/x/",
+}
+
+// WithExtendedStdlibGroup enables an extra group for `golang.org/x/...` imports (or other
+// prefixes provided by [WithExtendedStdlibPrefixes]), emitted right after the standard
+// library group by default.
+func WithExtendedStdlibGroup(enable bool) Option {
+	return func(conf *config) {
+		conf.groupExtendedStdlib = enable
+	}
+}
+
+// WithExtendedStdlibPrefixes overrides [DefaultExtendedStdlibPrefixes], allowing to treat other
+// "semi-standard" packages as part of the extended standard library group.
+func WithExtendedStdlibPrefixes(prefixes []string) Option {
+	return func(conf *config) {
+		conf.extendedStdlibPrefixes = prefixes
+	}
+}
+
+func (org *organizer) isExtendedStdlibImport(specPath string) bool {
+	prefixes := org.config.extendedStdlibPrefixes
+	if prefixes == nil {
+		prefixes = DefaultExtendedStdlibPrefixes
+	}
+	return hasAnyPrefix(specPath, prefixes)
+}
+
+const (
+	// PathDepthHost splits third party imports by host (e.g. `github.com`, `k8s.io`).
+	PathDepthHost = 1
+	// PathDepthOrg splits third party imports by host and organization (e.g. `github.com/aws`).
+	PathDepthOrg = 2
+
+	defaultPathGroupMinSize = 2
+)
+
+// WithGroupByPathDepth splits the third party group into sub-groups of imports sharing the
+// first depth elements of their path, see [PathDepthHost] and [PathDepthOrg]. A depth of 0
+// disables splitting.
+//
+// Sub-groups smaller than the minimum size (see [WithGroupByPathMinSize]) are not split out,
+// their imports stay in the remaining third party group, which is emitted first. Sub-groups
+// follow ordered by their path prefix.
+func WithGroupByPathDepth(depth int) Option {
+	return func(conf *config) {
+		conf.thirdPartyPathDepth = depth
+	}
+}
+
+// WithGroupByPathMinSize sets the minimum number of imports a sub-group created by
+// [WithGroupByPathDepth] has to have to be split out. Defaults to 2.
+func WithGroupByPathMinSize(size int) Option {
+	return func(conf *config) {
+		conf.thirdPartyPathMinSize = size
+	}
+}
+
+// splitByPathDepth splits a group of specs into sub-groups by their path prefix.
+func (org *organizer) splitByPathDepth(group types.ImportGroup) []types.ImportGroup {
+	depth := org.config.thirdPartyPathDepth
+	if depth <= 0 {
+		return []types.ImportGroup{group}
+	}
+	minSize := org.config.thirdPartyPathMinSize
+	if minSize <= 0 {
+		minSize = defaultPathGroupMinSize
+	}
+
+	prefixSpecs := map[string][]*ast.ImportSpec{}
+	for _, s := range group.Specs {
+		prefix := pathPrefix(s, depth)
+		prefixSpecs[prefix] = append(prefixSpecs[prefix], s)
+	}
+
+	var (
+		rest     types.ImportGroup
+		prefixes []string
+	)
+	for _, s := range group.Specs {
+		prefix := pathPrefix(s, depth)
+		if len(prefixSpecs[prefix]) < minSize {
+			rest.Specs = append(rest.Specs, s)
+		}
+	}
+	for prefix, specs := range prefixSpecs {
+		if len(specs) >= minSize {
+			prefixes = append(prefixes, prefix)
+		}
+	}
+	sort.Strings(prefixes)
+
+	var result []types.ImportGroup
+	if len(rest.Specs) > 0 {
+		result = append(result, rest)
+	}
+	for _, prefix := range prefixes {
+		result = append(result, types.ImportGroup{Specs: prefixSpecs[prefix]})
+	}
+	return result
+}
+
+// pathPrefix returns the first depth elements of the spec import path.
+func pathPrefix(s *ast.ImportSpec, depth int) string {
+	specPath, _ := strconv.Unquote(s.Path.Value)
+	elems := strings.Split(specPath, "/")
+	if len(elems) > depth {
+		elems = elems[:depth]
+	}
+	return strings.Join(elems, "/")
+}
+
+func (org *organizer) classifySpec(s *ast.ImportSpec) GroupKind {
+	specPath, _ := strconv.Unquote(s.Path.Value)
+	specPathParts := strings.Split(specPath, "/")
+
+	switch {
+	case org.config.groupSideEffects && isSideEffectImport(s):
+		return GroupSideEffect
+	case org.config.packageUnderTest != PackageUnderTestInline && org.isPackageUnderTestImport(s, specPath):
+		return GroupPackageUnderTest
+	case org.config.groupDotImports && isDotImport(s):
+		return GroupDot
+	case org.config.groupAliasImports && isAliasedImport(s, specPath):
+		return GroupAlias
+	case org.config.groupTestHelpers && org.isTestFile() && org.isTestHelperImport(specPath):
+		return GroupTestHelper
+	case org.config.isStdlibGroup(s, specPath):
+		return GroupStdlib
+	case org.config.groupExtendedStdlib && org.isExtendedStdlibImport(specPath):
+		return GroupExtendedStdlib
+	case org.config.groupInternalImports && org.isInternalGroupImport(specPath):
+		return GroupInternal
+	case org.config.groupNoDotImports && !strings.Contains(specPathParts[0], "."):
+		return GroupNoDot
+	case org.config.isLocalGroup(s, specPath):
+		return GroupLocal
+	default:
+		return GroupThirdParty
+	}
+}
+
+// groupOrder returns the configured group order completed with the default order.
+func (org *organizer) groupOrder() []GroupKind {
+	seen := map[GroupKind]bool{}
+
+	var order []GroupKind
+	if org.config.packageUnderTest == PackageUnderTestLeading {
+		order = append(order, GroupPackageUnderTest)
+	}
+	for _, kinds := range [][]GroupKind{org.config.groupOrder, DefaultGroupOrder} {
+		for _, kind := range kinds {
+			if !seen[kind] {
+				seen[kind] = true
+				order = append(order, kind)
+			}
+		}
+	}
+	if org.config.packageUnderTest == PackageUnderTestTrailing {
+		order = append(order, GroupPackageUnderTest)
+	}
+	return order
+}
+
+// forcedGroup returns the group named by the `//gofancyimports:group=<name>` directive of
+// a spec, if the name is a group kind in use (see [UnknownGroupDirectives]).
+func (org *organizer) forcedGroup(name string) (GroupKind, bool) {
+	if name == "" {
+		return "", false
+	}
+	for _, kind := range org.groupOrder() {
+		if kind == GroupKind(name) {
+			return kind, true
+		}
+	}
+	return "", false
+}
+
+// UnknownGroupDirectives returns imports of the file whose `//gofancyimports:group=<name>`
+// directive does not name a group kind of [DefaultGroupOrder] or [GroupPackageUnderTest],
+// in the order of the source. The organizer ignores such directives and groups the imports
+// as if they had none, which is also the fallback for the package under test group unless
+// its placement is leading or trailing.
+func UnknownGroupDirectives(file *ast.File) []*ast.ImportSpec {
+	var unknown []*ast.ImportSpec
+	for _, s := range file.Imports {
+		if name, ok := types.DirectiveValue(s.Comment, types.DirectiveGroup); ok && !isKnownGroup(GroupKind(name)) {
+			unknown = append(unknown, s)
+		}
+	}
+	return unknown
+}
+
+// CheckGroupDirectives returns an error when a `//gofancyimports:group=<name>` directive in the
+// file names an unknown group (see [UnknownGroupDirectives]).
+func CheckGroupDirectives(file *ast.File) error {
+	unknown := UnknownGroupDirectives(file)
+	if len(unknown) == 0 {
+		return nil
+	}
+	name, _ := types.DirectiveValue(unknown[0].Comment, types.DirectiveGroup)
+	return fmt.Errorf("unknown group %q in directive of import %s: expected one of %s",
+		name, unknown[0].Path.Value, strings.Join(knownGroupNames(), ", "))
+}
+
+func isKnownGroup(kind GroupKind) bool {
+	if kind == GroupPackageUnderTest {
+		return true
+	}
+	for _, known := range DefaultGroupOrder {
+		if kind == known {
+			return true
+		}
+	}
+	return false
+}
+
+func knownGroupNames() []string {
+	names := make([]string, 0, len(DefaultGroupOrder)+1)
+	for _, kind := range DefaultGroupOrder {
+		names = append(names, string(kind))
+	}
+	return append(names, string(GroupPackageUnderTest))
+}
+
+func isSideEffectImport(s *ast.ImportSpec) bool {
+	return s.Name != nil && s.Name.Name == "_"
+}
+
+func isDotImport(s *ast.ImportSpec) bool {
+	return s.Name != nil && s.Name.Name == "."
+}
+
+func (org *organizer) isInternalGroupImport(specPath string) bool {
+	if !isInternalPath(specPath) {
+		return false
+	}
+	if module := org.config.internalGroupModule; module != "" {
+		return specPath == module || strings.HasPrefix(specPath, module+"/")
+	}
+	return true
+}
+
+func isInternalPath(importPath string) bool {
+	_, ok := internalParent(importPath)
+	return ok
+}
+
+// internalParent returns the path of the directory containing the last `internal`
+// element of the import path.
+func internalParent(importPath string) (string, bool) {
+	elems := strings.Split(importPath, "/")
+	for i := len(elems) - 1; i >= 0; i-- {
+		if elems[i] == "internal" {
+			return strings.Join(elems[:i], "/"), true
+		}
+	}
+	return "", false
+}
+
+func isAliasedImport(s *ast.ImportSpec, specPath string) bool {
+	if s.Name == nil || isSideEffectImport(s) || isDotImport(s) {
+		return false
+	}
+	return s.Name.Name != assumedPackageName(specPath)
+}
@@ -0,0 +1,117 @@
+package autogroup
+
+import (
+	"go/ast"
+
+	"github.com/NonLogicalDev/gofancyimports/pkg/types"
+)
+
+// DeclarationLayout controls how organized imports are laid out into import declarations.
+type DeclarationLayout int
+
+const (
+	// MergeUndocumented merges all declarations without a doc comment into one declaration,
+	// while declarations with a doc comment stay separate. This is the default.
+	MergeUndocumented DeclarationLayout = iota
+	// MergeAll merges all declarations into one, the doc comment of a declaration becomes
+	// the header of its first group, which makes the group sticky.
+	MergeAll
+	// OnePerGroup emits a separate declaration for every group, the header of a group
+	// becomes the doc comment of its declaration.
+	OnePerGroup
+)
+
+// SingleImportStyle controls how declarations containing a single import are written.
+type SingleImportStyle int
+
+const (
+	// SingleImportBare writes declarations with a single import without parenthesis
+	// (`import "fmt"`). This is the default.
+	SingleImportBare SingleImportStyle = iota
+	// SingleImportParenthesized always writes declarations with parenthesis (`import ( "fmt" )`).
+	SingleImportParenthesized
+)
+
+// ParseDeclarationLayout parses layout name (e.g. from command line flags): `merge-undocumented`
+// (or empty string), `merge-all` or `one-per-group`.
+func ParseDeclarationLayout(name string) (DeclarationLayout, bool) {
+	switch name {
+	case "", "merge-undocumented":
+		return MergeUndocumented, true
+	case "merge-all":
+		return MergeAll, true
+	case "one-per-group":
+		return OnePerGroup, true
+	default:
+		return MergeUndocumented, false
+	}
+}
+
+// WithDeclarationLayout controls how organized imports are laid out into import declarations.
+// Declarations of cgo imports and declarations marked with the keep directive are never merged
+// or split.
+func WithDeclarationLayout(layout DeclarationLayout) Option {
+	return func(conf *config) {
+		conf.declLayout = layout
+	}
+}
+
+// WithSingleImportStyle controls whether declarations containing a single import are written
+// with parenthesis. Declarations of cgo imports and declarations marked with the keep directive
+// are not affected.
+func WithSingleImportStyle(style SingleImportStyle) Option {
+	return func(conf *config) {
+		conf.singleImportStyle = style
+	}
+}
+
+// docToGroupHeader moves the doc comment of the declaration to its first group.
+func docToGroupHeader(d types.ImportDeclaration) types.ImportDeclaration {
+	groups := make([]types.ImportGroup, len(d.ImportGroups))
+	copy(groups, d.ImportGroups)
+
+	for i := range groups {
+		if len(groups[i].Specs) == 0 {
+			continue
+		}
+		groups[i].Doc = types.MergeDocComments(nonNilComments(d.Doc, groups[i].Doc))
+		break
+	}
+	d.ImportGroups = groups
+	d.Doc = nil
+	return d
+}
+
+// splitPerGroup splits the declaration into one declaration per group. Comments of the
+// declaration stay with the first resulting declaration.
+func splitPerGroup(d types.ImportDeclaration) []types.ImportDeclaration {
+	var result []types.ImportDeclaration
+	for _, g := range d.ImportGroups {
+		if len(g.Specs) == 0 {
+			continue
+		}
+
+		split := types.ImportDeclaration{}
+		if len(result) == 0 {
+			split.LeadingComments = d.LeadingComments
+			split.DetachedComments = d.DetachedComments
+			split.Doc = d.Doc
+		}
+		if split.Doc == nil {
+			split.Doc, g.Doc = g.Doc, nil
+		}
+		split.ImportGroups = []types.ImportGroup{g}
+		result = append(result, split)
+	}
+	return result
+}
+
+func nonNilComments(groups ...*ast.CommentGroup) []*ast.CommentGroup {
+	var result []*ast.CommentGroup
+	for _, g := range groups {
+		if g != nil {
+			result = append(result, g)
+		}
+	}
+	return result
+}
@@ -0,0 +1,374 @@
+package autogroup
+
+import (
+	"go/ast"
+	"go/token"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/NonLogicalDev/gofancyimports/internal/astutils"
+	"github.com/NonLogicalDev/gofancyimports/internal/stdlib"
+	"github.com/NonLogicalDev/gofancyimports/pkg/types"
+)
+
+// DefaultStdlibMigrations maps deprecated standard library symbols to their drop-in
+// replacements, keyed and valued by the symbol qualified with the package path.
+var DefaultStdlibMigrations = map[string]string{
+	"io/ioutil.Discard":   "io.Discard",
+	"io/ioutil.NopCloser": "io.NopCloser",
+	"io/ioutil.ReadAll":   "io.ReadAll",
+	"io/ioutil.ReadFile":  "os.ReadFile",
+	"io/ioutil.WriteFile": "os.WriteFile",
+	"io/ioutil.TempDir":   "os.MkdirTemp",
+	"io/ioutil.TempFile":  "os.CreateTemp",
+
+	"os.SEEK_SET": "io.SeekStart",
+	"os.SEEK_CUR": "io.SeekCurrent",
+	"os.SEEK_END": "io.SeekEnd",
+
+	"reflect.Ptr": "reflect.Pointer",
+}
+
+// WithSymbolMigrations enables rewriting of references to symbols present in the migrations
+// table (see [DefaultStdlibMigrations]) into references to their replacements, e.g.
+// `ioutil.ReadFile` into `os.ReadFile`. Imports of the replacement packages are added and
+// grouped as any other import, imports that are no longer referenced are removed. Added imports
+// are aliased when their name is declared anywhere in the file, including local scopes, and
+// references are not migrated to existing imports shadowed by local identifiers.
+//
+// Migrations to standard library symbols newer than goVersion (as found in the `go`
+// directive of `go.mod`, empty means latest) are skipped.
+//
+// Requires [WithFile].
+func WithSymbolMigrations(goVersion string, migrations map[string]string) Option {
+	return func(conf *config) {
+		conf.migrationsGoVersion = goVersion
+		if conf.symbolMigrations == nil {
+			conf.symbolMigrations = map[string]string{}
+		}
+		for from, to := range migrations {
+			conf.symbolMigrations[from] = to
+		}
+	}
+}
+
+// splitQualifiedSymbol splits a symbol qualified by the package path (`io/ioutil.ReadAll`).
+func splitQualifiedSymbol(qualified string) (importPath string, name string, ok bool) {
+	i := strings.LastIndex(qualified, ".")
+	if i <= 0 || i == len(qualified)-1 || strings.Contains(qualified[i:], "/") {
+		return "", "", false
+	}
+	return qualified[:i], qualified[i+1:], true
+}
+
+// migrateSymbols rewrites references to migrated symbols, returning the identifier renames.
+// Imports in declarations marked with the keep directive are neither migrated nor removed.
+func (org *organizer) migrateSymbols(decls []types.ImportDeclaration) ([]types.ImportDeclaration, map[*ast.Ident]string) {
+	if org.config.file == nil || len(org.config.symbolMigrations) == 0 {
+		return decls, nil
+	}
+
+	// Imports available under every name, along with the names taken by imports.
+	var (
+		imported   = map[string]*ast.ImportSpec{}
+		importedAs = map[string]string{}
+		taken      = map[string]bool{}
+	)
+	for _, d := range decls {
+		for _, g := range d.ImportGroups {
+			for _, s := range g.Specs {
+				name := org.importName(s)
+				specPath, _ := strconv.Unquote(s.Path.Value)
+				taken[name] = true
+				if name == "_" || name == "." {
+					continue
+				}
+				if !d.Keep {
+					imported[name] = s
+				}
+				if _, ok := importedAs[specPath]; !ok {
+					importedAs[specPath] = name
+				}
+			}
+		}
+	}
+
+	type migration struct {
+		sel                    *ast.SelectorExpr
+		targetPath, targetName string
+	}
+	var (
+		migrations []migration
+		references = map[*ast.ImportSpec]int{}
+		locals     = map[string]bool{}
+	)
+	for _, name := range astutils.LocalNames(org.config.file) {
+		locals[name] = true
+	}
+	for _, sel := range astutils.PackageSelectors(org.config.file) {
+		s, ok := imported[sel.X.(*ast.Ident).Name]
+		if !ok {
+			continue
+		}
+		references[s]++
+
+		specPath, _ := strconv.Unquote(s.Path.Value)
+		targetPath, targetName, ok := org.migrationTarget(specPath, sel.Sel.Name)
+		if !ok {
+			continue
+		}
+		// References can not move to an existing import shadowed by a local identifier.
+		if name, ok := importedAs[targetPath]; ok && targetPath != specPath && locals[name] {
+			continue
+		}
+		migrations = append(migrations, migration{sel: sel, targetPath: targetPath, targetName: targetName})
+		if targetPath != specPath {
+			references[s]--
+		}
+	}
+	if len(migrations) == 0 {
+		return decls, nil
+	}
+
+	// Names of imports that are going away may be reused by added imports.
+	for name, s := range imported {
+		if count, ok := references[s]; ok && count == 0 {
+			specPath, _ := strconv.Unquote(s.Path.Value)
+			delete(taken, name)
+			delete(importedAs, specPath)
+		}
+	}
+	for _, name := range astutils.DeclaredNames(org.config.file) {
+		taken[name] = true
+	}
+	for name := range locals {
+		taken[name] = true
+	}
+
+	var (
+		renames = map[*ast.Ident]string{}
+		added   types.ImportGroup
+	)
+	for _, m := range migrations {
+		name, ok := importedAs[m.targetPath]
+		if !ok {
+			name = AliasScheme(AliasParentDir).Alias(m.targetPath, assumedPackageName(m.targetPath), taken)
+			taken[name] = true
+			importedAs[m.targetPath] = name
+			added.Specs = append(added.Specs, makeImportSpec(name, m.targetPath))
+		}
+		renames[m.sel.X.(*ast.Ident)] = name
+		renames[m.sel.Sel] = m.targetName
+	}
+
+	// Remove imports that are no longer referenced.
+	for declIdx := range decls {
+		if decls[declIdx].Keep {
+			continue
+		}
+		for groupIdx := range decls[declIdx].ImportGroups {
+			group := &decls[declIdx].ImportGroups[groupIdx]
+
+			var specs []*ast.ImportSpec
+			for _, s := range group.Specs {
+				if count, ok := references[s]; !ok || count > 0 {
+					specs = append(specs, s)
+				}
+			}
+			group.Specs = specs
+		}
+	}
+
+	if len(added.Specs) > 0 {
+		sortSpecs(added.Specs)
+		decls = append(decls, types.ImportDeclaration{
+			ImportGroups: []types.ImportGroup{added},
+		})
+	}
+	return decls, renames
+}
+
+// migrationTarget returns the replacement of the symbol if it has to be migrated.
+func (org *organizer) migrationTarget(importPath string, name string) (string, string, bool) {
+	target, ok := org.config.symbolMigrations[importPath+"."+name]
+	if !ok {
+		return "", "", false
+	}
+	targetPath, targetName, ok := splitQualifiedSymbol(target)
+	if !ok {
+		return "", "", false
+	}
+
+	if maxVersion, ok := stdlib.ParseGoVersion(org.config.migrationsGoVersion); ok && stdlib.IsStdlib(targetPath) {
+		if introduced, known := stdlib.SymbolVersion(targetPath, targetName); known && introduced > maxVersion {
+			return "", "", false
+		}
+	}
+	return targetPath, targetName, true
+}
+
+// mergeIdentRenames merges identifier renames, later renames taking precedence.
+func mergeIdentRenames(renames ...map[*ast.Ident]string) map[*ast.Ident]string {
+	var merged map[*ast.Ident]string
+	for _, r := range renames {
+		for ident, name := range r {
+			if merged == nil {
+				merged = map[*ast.Ident]string{}
+			}
+			merged[ident] = name
+		}
+	}
+	return merged
+}
+
+// WithImportPathMigrations enables rewriting of import paths according to the migrations
+// table mapping old paths to new paths. A mapping also applies to packages nested under the
+// old path (`github.com/old/lib` -> `github.com/acme/lib/v2` moves `github.com/old/lib/sub`
+// to `github.com/acme/lib/v2/sub`).
+//
+// When the package name changes, the import is aliased to the old name, or with
+// rewriteReferences the references in the file are renamed to the new name instead (falling
+// back to the alias when the new name is taken or declared anywhere in the file, including
+// local scopes). Imports of a path that is already imported are merged into the existing import.
+//
+// Renaming references requires [WithFile].
+func WithImportPathMigrations(migrations map[string]string, rewriteReferences bool) Option {
+	return func(conf *config) {
+		if conf.pathMigrations == nil {
+			conf.pathMigrations = map[string]string{}
+		}
+		for from, to := range migrations {
+			conf.pathMigrations[from] = to
+		}
+		conf.rewriteMigratedReferences = rewriteReferences
+	}
+}
+
+// migratedPath returns the new path of the import, preferring the most specific mapping.
+func (org *organizer) migratedPath(importPath string) (string, bool) {
+	var (
+		best     string
+		bestFrom string
+	)
+	for from, to := range org.config.pathMigrations {
+		if len(from) <= len(bestFrom) {
+			continue
+		}
+		if importPath == from {
+			best, bestFrom = to, from
+		} else if rest, ok := strings.CutPrefix(importPath, from+"/"); ok {
+			best, bestFrom = path.Join(to, rest), from
+		}
+	}
+	return best, bestFrom != ""
+}
+
+// migrateImportPaths rewrites import paths, returning renames of references to migrated
+// imports. Imports in declarations marked with the keep directive are left untouched.
+func (org *organizer) migrateImportPaths(decls []types.ImportDeclaration) ([]types.ImportDeclaration, map[*ast.Ident]string) {
+	if len(org.config.pathMigrations) == 0 {
+		return decls, nil
+	}
+
+	var (
+		importedAs = map[string]string{}
+		taken      = map[string]bool{}
+	)
+	for _, d := range decls {
+		for _, g := range d.ImportGroups {
+			for _, s := range g.Specs {
+				specPath, _ := strconv.Unquote(s.Path.Value)
+				if _, migrated := org.migratedPath(specPath); migrated && !d.Keep {
+					continue
+				}
+				name := org.importName(s)
+				taken[name] = true
+				if _, ok := importedAs[specPath]; !ok && name != "_" && name != "." {
+					importedAs[specPath] = name
+				}
+			}
+		}
+	}
+
+	var selectors []*ast.SelectorExpr
+	if org.config.file != nil {
+		for _, name := range astutils.DeclaredNames(org.config.file) {
+			taken[name] = true
+		}
+		for _, name := range astutils.LocalNames(org.config.file) {
+			taken[name] = true
+		}
+		selectors = astutils.PackageSelectors(org.config.file)
+	}
+
+	// renamed maps old names of migrated imports to the names they are available under.
+	renamed := map[string]string{}
+	for declIdx := range decls {
+		if decls[declIdx].Keep {
+			continue
+		}
+		for groupIdx := range decls[declIdx].ImportGroups {
+			group := &decls[declIdx].ImportGroups[groupIdx]
+
+			var specs []*ast.ImportSpec
+			for _, s := range group.Specs {
+				specPath, _ := strconv.Unquote(s.Path.Value)
+				newPath, ok := org.migratedPath(specPath)
+				if !ok {
+					specs = append(specs, s)
+					continue
+				}
+
+				oldName := org.importName(s)
+				s.Path = &ast.BasicLit{
+					ValuePos: s.Path.ValuePos,
+					Kind:     token.STRING,
+					Value:    strconv.Quote(newPath),
+				}
+
+				// Side effect and dot imports keep their names.
+				if oldName == "_" || oldName == "." {
+					specs = append(specs, s)
+					continue
+				}
+
+				// The path is already imported, references move to the existing import.
+				if existing, ok := importedAs[newPath]; ok {
+					if existing != oldName {
+						renamed[oldName] = existing
+					}
+					continue
+				}
+
+				newName := assumedPackageName(newPath)
+				switch {
+				case s.Name != nil && s.Name.Name != newName:
+					// Explicit aliases are preserved.
+				case oldName == newName:
+					s.Name = nil
+				case org.config.rewriteMigratedReferences && org.config.file != nil && !taken[newName]:
+					s.Name = nil
+					renamed[oldName] = newName
+				default:
+					s.Name = &ast.Ident{NamePos: s.Pos(), Name: oldName}
+				}
+
+				name := org.importName(s)
+				taken[name] = true
+				importedAs[newPath] = name
+				specs = append(specs, s)
+			}
+			group.Specs = specs
+		}
+	}
+
+	renames := map[*ast.Ident]string{}
+	for _, sel := range selectors {
+		x := sel.X.(*ast.Ident)
+		if name, ok := renamed[x.Name]; ok {
+			renames[x] = name
+		}
+	}
+	return decls, renames
+}
@@ -3,6 +3,7 @@ package autogroup
 import (
 	"fmt"
 	"go/ast"
+	"go/token"
 	astTypes "go/types"
 	"path"
 	"sort"
@@ -20,11 +21,57 @@ type (
 	config struct {
 		groupSideEffects  bool
 		groupNoDotImports bool
+		groupDotImports   bool
+		groupAliasImports bool
+
+		groupExtendedStdlib    bool
+		extendedStdlibPrefixes []string
+
+		groupInternalImports bool
+		internalGroupModule  string
+
+		thirdPartyPathDepth   int
+		thirdPartyPathMinSize int
+
+		groupTestHelpers   bool
+		testHelperPackages []string
+		packageUnderTest   PackageUnderTestPlacement
+		packagePath        string
+
+		groupOrder []GroupKind
+
+		stickyPlacement     StickyGroupPlacement
+		preserveStickyOrder bool
+
+		commentPlacement CommentPlacement
+		dedupDocComments bool
+
+		declLayout        DeclarationLayout
+		singleImportStyle SingleImportStyle
 
 		isLocalGroup  GroupMatcher
 		isStdlibGroup GroupMatcher
 
 		specFixups []SpecFixup
+
+		collisionAliases AliasScheme
+		pkgTypeInfo      map[string]*astTypes.Package
+
+		symbolMigrations    map[string]string
+		migrationsGoVersion string
+
+		pathMigrations            map[string]string
+		rewriteMigratedReferences bool
+
+		importAliases     map[string]string
+		consistentAliases bool
+
+		fset            *token.FileSet
+		file            *ast.File
+		packageDecls    []string
+		importResolvers []ImportResolver
+
+		directiveImports bool
 	}
 
 	// Option represents configurable option for autogroup transform.
@@ -43,6 +90,7 @@ type (
 // Examples:
 //   - FixupDefaultImportAlias - ensure import alias is added if last component of path does not match package name.
 //   - FixupEmbedPackage - ensure a comment is added to side effect import of embed package to appease linters.
+//   - FixupBlankImportComments - ensure a comment is added to side effect imports of well known packages.
 func WithSpecFixups(fixups ...SpecFixup) Option {
 	return func(conf *config) {
 		conf.specFixups = append(conf.specFixups, fixups...)
@@ -93,6 +141,33 @@ func WithCustomLocalGroupMatcher(lookup GroupMatcher) Option {
 	}
 }
 
+// DefaultBlankImportComments contains justification comments for well known packages
+// that are commonly imported purely for side effects.
+var DefaultBlankImportComments = map[string]string{
+	"embed":          "enable embedding",
+	"unsafe":         "enable go:linkname",
+	"time/tzdata":    "embed timezone database",
+	"net/http/pprof": "register pprof HTTP handlers",
+	"expvar":         "register expvar HTTP handler",
+	"image/gif":      "register GIF image decoder",
+	"image/jpeg":     "register JPEG image decoder",
+	"image/png":      "register PNG image decoder",
+
+	"github.com/lib/pq":                      "register postgres database driver",
+	"github.com/jackc/pgx/stdlib":            "register pgx database driver",
+	"github.com/jackc/pgx/v4/stdlib":         "register pgx database driver",
+	"github.com/jackc/pgx/v5/stdlib":         "register pgx database driver",
+	"github.com/go-sql-driver/mysql":         "register mysql database driver",
+	"github.com/mattn/go-sqlite3":            "register sqlite3 database driver",
+	"modernc.org/sqlite":                     "register sqlite database driver",
+	"github.com/microsoft/go-mssqldb":        "register mssql database driver",
+	"github.com/denisenkom/go-mssqldb":       "register mssql database driver",
+	"github.com/ClickHouse/clickhouse-go":    "register clickhouse database driver",
+	"github.com/ClickHouse/clickhouse-go/v2": "register clickhouse database driver",
+	"github.com/snowflakedb/gosnowflake":     "register snowflake database driver",
+	"github.com/sijms/go-ora/v2":             "register oracle database driver",
+}
+
 func DefaultSpecFixups(pkgTypeInfo map[string]*astTypes.Package) []SpecFixup {
 	return []SpecFixup{
 		FixupEmbedPackage,
@@ -106,7 +181,46 @@ func FixupEmbedPackage(s *ast.ImportSpec) {
 
 	// Special treatment for well known packages.
 	if specPath == "embed" && s.Name != nil && s.Name.Name == "_" && s.Comment == nil {
-		s.Comment = makeLineComment("enable embedding")
+		s.Comment = makeLineComment(DefaultBlankImportComments["embed"])
+	}
+}
+
+// BlankImportComments returns a table of justification comments for [FixupBlankImportComments]:
+// the comment of `embed` (as added by [FixupEmbedPackage]), all [DefaultBlankImportComments]
+// when withDefaults is set, and comments given as `path=comment` entries (e.g. from command
+// line flags), which take precedence.
+func BlankImportComments(withDefaults bool, entries []string) (map[string]string, error) {
+	comments := map[string]string{
+		"embed": DefaultBlankImportComments["embed"],
+	}
+	if withDefaults {
+		for pkgPath, comment := range DefaultBlankImportComments {
+			comments[pkgPath] = comment
+		}
+	}
+	for _, entry := range entries {
+		pkgPath, comment, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid blank import comment %q: expected path=comment", entry)
+		}
+		comments[strings.TrimSpace(pkgPath)] = strings.TrimSpace(comment)
+	}
+	return comments, nil
+}
+
+// FixupBlankImportComments ensures side effect only imports of packages present in the
+// provided table have a comment justifying the import, to avoid getting flagged by linters.
+//
+// Imports that already have a comment are left untouched.
+func FixupBlankImportComments(comments map[string]string) SpecFixup {
+	return func(s *ast.ImportSpec) {
+		if s.Name == nil || s.Name.Name != "_" || s.Comment != nil || s.Doc != nil {
+			return
+		}
+		specPath, _ := strconv.Unquote(s.Path.Value)
+		if comment, ok := comments[specPath]; ok {
+			s.Comment = makeLineComment(comment)
+		}
 	}
 }
 
@@ -155,69 +269,115 @@ func New(opts ...Option) types.ImportTransform {
 
 func (org *organizer) organiseImports(decls []types.ImportDeclaration) []types.ImportDeclaration {
 	var (
-		cGroup        *types.ImportDeclaration
 		defaultGroups []types.ImportDeclaration
-		stickyGroups  []types.ImportDeclaration
+		stickyGroups  []stickyItem[types.ImportDeclaration]
 	)
 
 	var floatingComments []*ast.CommentGroup
 
+	// Imports of "C" are never organized, they stay directly below their preambles.
+	cgoDecls, decls := splitCgoImports(decls)
+	for i := range cgoDecls {
+		floatingComments = append(floatingComments, cgoDecls[i].LeadingComments...)
+		cgoDecls[i].LeadingComments = nil
+	}
+
+	decls = org.addMissingImports(decls)
+	decls = org.fixupDirectiveImports(decls)
+	decls = org.attractPatternSpecs(decls)
+	decls, pathRenames := org.migrateImportPaths(decls)
+	decls, symbolRenames := org.migrateSymbols(decls)
+	decls, aliasRenames := org.setImportAliases(decls)
+	identRenames := mergeIdentRenames(pathRenames, symbolRenames, aliasRenames, org.resolveNameCollisions(decls))
+
+	anchoredComments := org.anchorComments(decls)
+	org.attachAnchoredComments(decls, anchoredComments)
 	for _, d := range decls {
 		d := d
-		if len(d.ImportGroups) == 0 {
-			continue
-		}
 
 		// Gather Floating comments in one group.
 		floatingComments = append(floatingComments, d.LeadingComments...)
 		d.LeadingComments = nil
 
-		if len(d.ImportGroups[0].Specs) != 0 && d.ImportGroups[0].Specs[0].Path.Value == `"C"` {
-			cGroup = &d
+		if !hasSpecs(d) {
 			continue
 		}
 
-		if d.Doc == nil {
+		if org.config.declLayout == MergeAll && d.Doc != nil && !d.Keep {
+			d = docToGroupHeader(d)
+		}
+
+		if d.Doc == nil && !d.Keep {
 			defaultGroups = append(defaultGroups, d)
 		} else {
-			stickyGroups = append(stickyGroups, d)
+			stickyGroups = append(stickyGroups, stickyItem[types.ImportDeclaration]{item: d, after: len(defaultGroups)})
 		}
 	}
 
-	var resultGroups []types.ImportDeclaration
-	if cGroup != nil {
-		resultGroups = append(resultGroups, *cGroup)
-	}
+	var autoGroups []types.ImportDeclaration
 	if len(defaultGroups) > 0 {
 		mergedDefaultGroup := types.MergeDeclarations(defaultGroups)
-		mergedDefaultGroup.ImportGroups = org.organizeImportGroups(mergedDefaultGroup.ImportGroups)
+		mergedDefaultGroup.ImportGroups = org.organizeImportGroups(mergedDefaultGroup.ImportGroups, true)
 
-		resultGroups = append(resultGroups, mergedDefaultGroup)
+		autoGroups = append(autoGroups, mergedDefaultGroup)
 	}
-	for _, group := range stickyGroups {
-		group.ImportGroups = org.organizeImportGroups(group.ImportGroups)
-		resultGroups = append(resultGroups, group)
+	for i := range stickyGroups {
+		// Declarations marked with the keep directive are left untouched.
+		// When sorting of sticky groups is disabled, sticky declarations are still fixed up
+		// and grouped, but specs keep their order.
+		if group := &stickyGroups[i].item; !group.Keep {
+			group.ImportGroups = org.organizeImportGroups(group.ImportGroups, !org.config.preserveStickyOrder)
+		}
 	}
 
-	// Add all floating comments to the first available group.
+	var resultGroups []types.ImportDeclaration
+	for _, d := range placeSticky(org.config.stickyPlacement, autoGroups, stickyGroups, len(defaultGroups)) {
+		if d.Keep {
+			resultGroups = append(resultGroups, d)
+			continue
+		}
+
+		var laidOut []types.ImportDeclaration
+		if org.config.declLayout == OnePerGroup {
+			laidOut = splitPerGroup(d)
+		} else {
+			laidOut = []types.ImportDeclaration{d}
+		}
+		for _, d := range laidOut {
+			d.Parenthesized = org.config.singleImportStyle == SingleImportParenthesized
+			if org.config.dedupDocComments {
+				org.dedupDocComments(&d)
+			}
+			resultGroups = append(resultGroups, d)
+		}
+	}
+
+	if anchoredComments != nil {
+		floatingComments = append(floatingComments, placeAnchoredComments(resultGroups, anchoredComments)...)
+	}
+
+	// Add all floating comments to the first available group, keeping them out of cgo
+	// preambles whenever possible.
 	if len(resultGroups) > 0 {
 		resultGroups[0].LeadingComments = floatingComments
+	} else if len(cgoDecls) > 0 {
+		cgoDecls[0].LeadingComments = floatingComments
+	}
+
+	resultGroups = append(cgoDecls, resultGroups...)
+	if len(resultGroups) > 0 && identRenames != nil {
+		resultGroups[0].IdentRenames = identRenames
 	}
 
 	return resultGroups
 }
 
-func (org *organizer) organizeImportGroups(groups []types.ImportGroup) []types.ImportGroup {
+// organizeImportGroups fixes up specs and splits them into groups. When sorted is false,
+// specs keep their relative order.
+func (org *organizer) organizeImportGroups(groups []types.ImportGroup, sorted bool) []types.ImportGroup {
 	var (
 		defaultGroups []types.ImportGroup
-
-		defaultStdGroup        types.ImportGroup
-		defaultNoDotGroup      types.ImportGroup
-		defaultLocalGroup      types.ImportGroup
-		defaultThridPartyGroup types.ImportGroup
-		defaultEffectGropup    types.ImportGroup
-
-		stickyGroups []types.ImportGroup
+		stickyGroups  []stickyItem[types.ImportGroup]
 	)
 
 	for _, g := range groups {
@@ -236,58 +396,68 @@ func (org *organizer) organizeImportGroups(groups []types.ImportGroup) []types.I
 		if g.Doc == nil {
 			defaultGroups = append(defaultGroups, g)
 		} else {
-			stickyGroups = append(stickyGroups, g)
+			stickyGroups = append(stickyGroups, stickyItem[types.ImportGroup]{item: g, after: len(defaultGroups)})
 		}
 	}
 
 	var result []types.ImportGroup
 	if len(defaultGroups) > 0 {
 		defaultGroup := types.MergeGroups(defaultGroups)
+
+		kindGroups := map[GroupKind]*types.ImportGroup{}
 		for _, s := range defaultGroup.Specs {
-			specPath, _ := strconv.Unquote(s.Path.Value)
-			specPathParts := strings.Split(specPath, "/")
-
-			if org.config.groupSideEffects && s.Name != nil && s.Name.Name == "_" {
-				defaultEffectGropup.Specs = append(defaultEffectGropup.Specs, s)
-			} else if org.config.isStdlibGroup(s, specPath) {
-				defaultStdGroup.Specs = append(defaultStdGroup.Specs, s)
-			} else if org.config.groupNoDotImports && !strings.Contains(specPathParts[0], ".") {
-				defaultNoDotGroup.Specs = append(defaultNoDotGroup.Specs, s)
-			} else if org.config.isLocalGroup(s, specPath) {
-				defaultLocalGroup.Specs = append(defaultLocalGroup.Specs, s)
+			kind, forced := org.forcedGroup(defaultGroup.SpecGroups[s])
+			if !forced {
+				kind = org.classifySpec(s)
+			}
+			if kindGroups[kind] == nil {
+				kindGroups[kind] = &types.ImportGroup{}
+			}
+			kindGroups[kind].Specs = append(kindGroups[kind].Specs, s)
+		}
+		for _, kind := range org.groupOrder() {
+			g := kindGroups[kind]
+			if g == nil {
+				continue
+			}
+			if kind == GroupThirdParty {
+				result = append(result, org.splitByPathDepth(*g)...)
 			} else {
-				defaultThridPartyGroup.Specs = append(defaultThridPartyGroup.Specs, s)
+				result = append(result, *g)
 			}
 		}
+	}
 
-		if len(defaultStdGroup.Specs) > 0 {
-			result = append(result, defaultStdGroup)
-		}
-		if len(defaultNoDotGroup.Specs) > 0 {
-			result = append(result, defaultNoDotGroup)
-		}
-		if len(defaultThridPartyGroup.Specs) > 0 {
-			result = append(result, defaultThridPartyGroup)
+	if sorted {
+		for _, r := range result {
+			sortSpecs(r.Specs)
 		}
-		if len(defaultLocalGroup.Specs) > 0 {
-			result = append(result, defaultLocalGroup)
-		}
-		if len(defaultEffectGropup.Specs) > 0 {
-			result = append(result, defaultEffectGropup)
+		for _, s := range stickyGroups {
+			if !s.item.NoSort && !org.config.preserveStickyOrder {
+				sortSpecs(s.item.Specs)
+			}
 		}
 	}
+	result = placeSticky(org.config.stickyPlacement, result, stickyGroups, len(defaultGroups))
 
-	result = append(result, stickyGroups...)
+	return result
+}
 
-	for _, r := range result {
-		sort.SliceStable(r.Specs, func(i, j int) bool {
-			iPath := r.Specs[i].Path.Value
-			jPath := r.Specs[j].Path.Value
-			return iPath < jPath
-		})
-	}
+func sortSpecs(specs []*ast.ImportSpec) {
+	sort.SliceStable(specs, func(i, j int) bool {
+		iPath := specs[i].Path.Value
+		jPath := specs[j].Path.Value
+		return iPath < jPath
+	})
+}
 
-	return result
+func hasSpecs(d types.ImportDeclaration) bool {
+	for _, g := range d.ImportGroups {
+		if len(g.Specs) > 0 {
+			return true
+		}
+	}
+	return false
 }
 
 func hasAnyPrefix(path string, prefixes []string) bool {
@@ -0,0 +1,101 @@
+package autogroup
+
+import (
+	"go/token"
+	"sort"
+
+	"github.com/NonLogicalDev/gofancyimports/pkg/types"
+)
+
+// FileOptions returns options specific to a file of a package (e.g. resolvers of its missing
+// imports), applied after the options shared by all files.
+type FileOptions func(file types.PackageFile) []Option
+
+// NewPackage returns a transform organizing the imports of every file of a package as [New]
+// does with [WithFile], applying the options shared by all files followed by the options
+// returned by fileOpts (which may be nil). Options operating across the files of the package
+// ([WithConsistentAliases]) take effect only in package transforms.
+func NewPackage(fileOpts FileOptions, opts ...Option) types.PackageTransform {
+	return func(fset *token.FileSet, files []types.PackageFile) [][]types.ImportDeclaration {
+		var conf config
+		for _, apply := range opts {
+			apply(&conf)
+		}
+
+		var targets map[string]string
+		if conf.consistentAliases {
+			targets = ConsistentImportNames(files)
+		}
+
+		results := make([][]types.ImportDeclaration, len(files))
+		for i, file := range files {
+			if file.Disabled {
+				results[i] = file.Decls
+				continue
+			}
+
+			orgOpts := append([]Option{WithFile(fset, file.File)}, opts...)
+			if fileOpts != nil {
+				orgOpts = append(orgOpts, fileOpts(file)...)
+			}
+
+			aliases := map[string]string{}
+			for importPath, name := range ImportNames(file.File) {
+				if target, ok := targets[importPath]; ok && name != target {
+					aliases[importPath] = target
+				}
+			}
+			if len(aliases) > 0 {
+				orgOpts = append(orgOpts, WithImportAliases(aliases))
+			}
+
+			results[i] = New(orgOpts...)(file.Decls)
+		}
+		return results
+	}
+}
+
+// WithConsistentAliases enables importing every path under the same name in all files of a
+// package (see [ConsistentImportNames]), renaming the references as well. Files where that
+// name is not usable (see [CheckImportAliases]) are left as they are.
+//
+// Only honoured by [NewPackage].
+func WithConsistentAliases(enable bool) Option {
+	return func(conf *config) {
+		conf.consistentAliases = enable
+	}
+}
+
+// ConsistentImportNames returns the names paths imported under different names by the files
+// are to be imported under: the names most files use, ties broken alphabetically. Files that
+// opted out of import rewriting are taken into account as well.
+func ConsistentImportNames(files []types.PackageFile) map[string]string {
+	usages := map[string]map[string]int{}
+	for _, file := range files {
+		for importPath, name := range ImportNames(file.File) {
+			if usages[importPath] == nil {
+				usages[importPath] = map[string]int{}
+			}
+			usages[importPath][name]++
+		}
+	}
+
+	targets := map[string]string{}
+	for importPath, counts := range usages {
+		if len(counts) < 2 {
+			continue
+		}
+		names := make([]string, 0, len(counts))
+		for name := range counts {
+			names = append(names, name)
+		}
+		sort.Slice(names, func(i, j int) bool {
+			if counts[names[i]] != counts[names[j]] {
+				return counts[names[i]] > counts[names[j]]
+			}
+			return names[i] < names[j]
+		})
+		targets[importPath] = names[0]
+	}
+	return targets
+}
@@ -0,0 +1,162 @@
+package autogroup
+
+import (
+	"go/ast"
+	"strconv"
+	"strings"
+
+	"github.com/NonLogicalDev/gofancyimports/pkg/types"
+)
+
+// groupPatternDirective is the header directive declaring import path patterns of a sticky
+// group with `pattern=` arguments.
+//
+// Example:
+//
+//	import (
+//		// gofancyimports:group pattern=github.com/acme/proto/...
+//		"github.com/acme/proto/billing"
+//	)
+const (
+	groupPatternDirective = types.DirectivePrefix + "group"
+	groupPatternArgument  = "pattern="
+)
+
+// groupTarget is a sticky group or declaration attracting specs matching its patterns.
+type groupTarget struct {
+	patterns []string
+	group    *types.ImportGroup
+}
+
+// attractPatternSpecs moves specs matching patterns of sticky groups declared by the
+// `gofancyimports:group pattern=...` header directive into these groups, keeping the
+// position and comments of the sticky groups intact.
+//
+// A directive on a declaration doc comment attracts specs into the first group of the
+// declaration. When several patterns match a spec, the longest pattern wins, with ties
+// going to the first group in the file. Comments of groups and declarations left without
+// specs become floating comments.
+func (org *organizer) attractPatternSpecs(decls []types.ImportDeclaration) []types.ImportDeclaration {
+	var targets []groupTarget
+	for declIdx := range decls {
+		d := &decls[declIdx]
+		if d.Keep {
+			continue
+		}
+		if patterns := groupPatterns(d.Doc); len(patterns) > 0 {
+			if len(d.ImportGroups) == 0 {
+				d.ImportGroups = append(d.ImportGroups, types.ImportGroup{})
+			}
+			targets = append(targets, groupTarget{patterns: patterns, group: &d.ImportGroups[0]})
+		}
+		for groupIdx := range d.ImportGroups {
+			g := &d.ImportGroups[groupIdx]
+			if patterns := groupPatterns(g.Doc); len(patterns) > 0 {
+				targets = append(targets, groupTarget{patterns: patterns, group: g})
+			}
+		}
+	}
+	if len(targets) == 0 {
+		return decls
+	}
+
+	moved := map[*types.ImportGroup][]*ast.ImportSpec{}
+	for declIdx := range decls {
+		d := &decls[declIdx]
+		if d.Keep {
+			continue
+		}
+		emptied := false
+		for groupIdx := range d.ImportGroups {
+			g := &d.ImportGroups[groupIdx]
+
+			var specs []*ast.ImportSpec
+			for _, s := range g.Specs {
+				target := matchGroupTarget(targets, s)
+				if target == nil || target == g {
+					specs = append(specs, s)
+					continue
+				}
+				moved[target] = append(moved[target], s)
+			}
+			if len(specs) == 0 && len(g.Specs) > 0 {
+				emptied = true
+				floatEmptiedGroupComments(d, g)
+			}
+			g.Specs = specs
+		}
+		if emptied && !hasSpecs(*d) {
+			floatEmptiedDeclarationComments(d)
+		}
+	}
+	for _, target := range targets {
+		target.group.Specs = append(target.group.Specs, moved[target.group]...)
+		delete(moved, target.group)
+	}
+	return decls
+}
+
+// floatEmptiedGroupComments turns comments of a group whose specs were all attracted elsewhere
+// into comments floating above the declaration, as groups without specs are not written.
+func floatEmptiedGroupComments(d *types.ImportDeclaration, g *types.ImportGroup) {
+	d.LeadingComments = append(d.LeadingComments, g.LeadingComments...)
+	if g.Doc != nil {
+		d.LeadingComments = append(d.LeadingComments, g.Doc)
+	}
+	g.LeadingComments, g.Doc = nil, nil
+}
+
+// floatEmptiedDeclarationComments turns comments of a declaration whose specs were all
+// attracted elsewhere into floating comments, as declarations without specs are not written.
+func floatEmptiedDeclarationComments(d *types.ImportDeclaration) {
+	var comments []*ast.CommentGroup
+	if d.Doc != nil {
+		comments = append(comments, d.Doc)
+	}
+	comments = append(comments, d.DetachedComments...)
+	d.LeadingComments = append(comments, d.LeadingComments...)
+	d.Doc, d.DetachedComments = nil, nil
+}
+
+// matchGroupTarget returns the group whose patterns match the spec most specifically.
+func matchGroupTarget(targets []groupTarget, s *ast.ImportSpec) *types.ImportGroup {
+	specPath, _ := strconv.Unquote(s.Path.Value)
+
+	var (
+		best       *types.ImportGroup
+		bestLength = -1
+	)
+	for _, target := range targets {
+		for _, pattern := range target.patterns {
+			if matchPathPattern(pattern, specPath) && len(pattern) > bestLength {
+				best, bestLength = target.group, len(pattern)
+			}
+		}
+	}
+	return best
+}
+
+// groupPatterns returns patterns declared by `gofancyimports:group pattern=...` directives in
+// the comment group. Patterns are comma separated, and the argument or the directive may be
+// repeated.
+func groupPatterns(doc *ast.CommentGroup) []string {
+	var patterns []string
+	for _, directive := range types.CommentDirectives(doc) {
+		fields := strings.Fields(directive)
+		if fields[0] != groupPatternDirective {
+			continue
+		}
+		for _, arg := range fields[1:] {
+			value, ok := strings.CutPrefix(arg, groupPatternArgument)
+			if !ok {
+				continue
+			}
+			for _, pattern := range strings.Split(value, ",") {
+				if pattern != "" {
+					patterns = append(patterns, pattern)
+				}
+			}
+		}
+	}
+	return patterns
+}
@@ -0,0 +1,75 @@
+package autogroup
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/NonLogicalDev/gofancyimports/internal/stdlib"
+)
+
+// DotImportPolicy restricts where dot imports are allowed. The zero value forbids all dot imports.
+//
+// The organizer never rewrites dot imports, the policy is meant to be enforced by linters
+// such as the autogroupimports analyzer.
+type DotImportPolicy struct {
+	// AllowInTests allows dot imports of any package in `_test.go` files.
+	AllowInTests bool
+
+	// Allowed lists import paths that may be dot imported in any file. Entries ending with
+	// `/...` match the path itself and every path below it.
+	Allowed []string
+}
+
+// ParseDotImportPolicy parses policy name (e.g. from command line flags): `any` (or empty
+// string) returning a nil policy as dot imports are not restricted, `tests` allowing dot
+// imports in `_test.go` files only, or `none`.
+func ParseDotImportPolicy(name string) (*DotImportPolicy, bool) {
+	switch name {
+	case "", "any":
+		return nil, true
+	case "tests":
+		return &DotImportPolicy{AllowInTests: true}, true
+	case "none":
+		return &DotImportPolicy{}, true
+	default:
+		return nil, false
+	}
+}
+
+// Allows reports whether dot importing importPath is allowed in the file.
+func (p DotImportPolicy) Allows(filename string, importPath string) bool {
+	if p.AllowInTests && strings.HasSuffix(filepath.Base(filename), "_test.go") {
+		return true
+	}
+	for _, allowed := range p.Allowed {
+		if matchPathPattern(allowed, importPath) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchPathPattern matches an import path against a pattern, which is either an exact
+// import path or a path ending with `/...` matching the path and all paths below it.
+func matchPathPattern(pattern string, importPath string) bool {
+	if prefix, ok := strings.CutSuffix(pattern, "/..."); ok {
+		return importPath == prefix || strings.HasPrefix(importPath, prefix+"/")
+	}
+	return importPath == pattern
+}
+
+// InternalImportAllowed reports whether package importerPath is allowed to import
+// importPath according to the visibility rule of `internal` packages: an internal package
+// may only be imported by packages rooted at the parent of the `internal` directory.
+func InternalImportAllowed(importerPath string, importPath string) bool {
+	parent, ok := internalParent(importPath)
+	if !ok {
+		return true
+	}
+	if parent == "" {
+		// Top level internal packages are only importable within the standard library or
+		// the module rooted at the same path, which can't be told apart from the path alone.
+		return stdlib.IsStdlib(importPath) == stdlib.IsStdlib(importerPath)
+	}
+	return importerPath == parent || strings.HasPrefix(importerPath, parent+"/")
+}
@@ -0,0 +1,200 @@
+package autogroup
+
+import (
+	"go/ast"
+	"go/token"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/NonLogicalDev/gofancyimports/internal/astutils"
+	"github.com/NonLogicalDev/gofancyimports/internal/stdlib"
+	"github.com/NonLogicalDev/gofancyimports/pkg/types"
+)
+
+// ImportResolver is a function that resolves a package reference that is not satisfied
+// by any of the file imports into an import path.
+//
+// The pkgName is the qualifier used in the file (i.e. `strings` in `strings.Cut`) and
+// symbols is the sorted list of all names selected from it in the file.
+type ImportResolver func(pkgName string, symbols []string) (importPath string, ok bool)
+
+// WithFile provides the organizer with the file that is being organized, enabling
+// features that depend on the contents of the file beyond its import declarations.
+//
+// Since the organizer is bound to the file, a new organizer has to be created for
+// every file, see WithFileTransform in the gofancyimports package.
+func WithFile(fset *token.FileSet, file *ast.File) Option {
+	return func(conf *config) {
+		conf.fset = fset
+		conf.file = file
+	}
+}
+
+// WithPackageDeclarations declares package level identifiers that are declared in
+// other files of the same package, so that references to them are not mistaken for
+// references to packages that are not imported.
+func WithPackageDeclarations(names []string) Option {
+	return func(conf *config) {
+		conf.packageDecls = append(conf.packageDecls, names...)
+	}
+}
+
+// WithMissingImportResolvers enables adding imports for package references in the file
+// that are not satisfied by existing imports. Resolvers are consulted in order and the
+// first one to resolve the reference wins. Added imports are grouped as any other import.
+//
+// Requires [WithFile].
+//
+// Examples:
+//   - ResolveFromImports - resolve references using imports of other files in the package.
+//   - ResolveStdlibImports - resolve references to the standard library packages.
+func WithMissingImportResolvers(resolvers ...ImportResolver) Option {
+	return func(conf *config) {
+		conf.importResolvers = append(conf.importResolvers, resolvers...)
+	}
+}
+
+// ResolveStdlibImports resolves references to standard library packages using the
+// embedded symbol table, only considering packages and symbols available as of the
+// provided Go version (as found in the `go` directive of `go.mod`, empty means latest).
+//
+// When several packages with the same name export all referenced symbols the choice is
+// deterministic, preferring shallower and then shorter import paths.
+func ResolveStdlibImports(goVersion string) ImportResolver {
+	return func(pkgName string, symbols []string) (string, bool) {
+		return stdlib.LookupPackage(pkgName, symbols, goVersion)
+	}
+}
+
+// ResolveFromImports resolves references using the provided imports, typically imports
+// of other files in the same package. A reference is resolved to the import that is
+// available under the same name, no matter which symbols are referenced.
+func ResolveFromImports(specs []*ast.ImportSpec) ImportResolver {
+	byName := map[string]string{}
+	for _, s := range specs {
+		name := importSpecName(s)
+		if name == "_" || name == "." {
+			continue
+		}
+		specPath, _ := strconv.Unquote(s.Path.Value)
+		if existing, ok := byName[name]; !ok || specPath < existing {
+			byName[name] = specPath
+		}
+	}
+
+	return func(pkgName string, _ []string) (string, bool) {
+		importPath, ok := byName[pkgName]
+		return importPath, ok
+	}
+}
+
+func (org *organizer) addMissingImports(decls []types.ImportDeclaration) []types.ImportDeclaration {
+	if org.config.file == nil || len(org.config.importResolvers) == 0 {
+		return decls
+	}
+
+	// Names of imports without an alias are only assumed from their paths, packages whose
+	// name differs from the last element of the path are recognized by their path instead.
+	// Side effect and dot imports provide no name.
+	declared := map[string]bool{}
+	imported := map[string]bool{}
+	blank := map[string]*ast.ImportSpec{}
+	for _, name := range org.config.packageDecls {
+		declared[name] = true
+	}
+	for _, d := range decls {
+		for _, g := range d.ImportGroups {
+			for _, s := range g.Specs {
+				specPath, _ := strconv.Unquote(s.Path.Value)
+				switch name := importSpecName(s); name {
+				case "_":
+					blank[specPath] = s
+				case ".":
+				default:
+					declared[name] = true
+					imported[specPath] = true
+				}
+			}
+		}
+	}
+
+	refs := astutils.UnresolvedSelectors(org.config.file)
+	names := make([]string, 0, len(refs))
+	for name := range refs {
+		if !declared[name] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var missing types.ImportGroup
+	for _, name := range names {
+		for _, resolve := range org.config.importResolvers {
+			importPath, ok := resolve(name, refs[name])
+			if !ok {
+				continue
+			}
+			if imported[importPath] {
+				break
+			}
+			imported[importPath] = true
+
+			// A side effect import of the package turns into the import providing the name.
+			if s, ok := blank[importPath]; ok {
+				s.Name = makeImportSpec(name, importPath).Name
+				break
+			}
+			missing.Specs = append(missing.Specs, makeImportSpec(name, importPath))
+			break
+		}
+	}
+	if len(missing.Specs) == 0 {
+		return decls
+	}
+
+	return append(decls, types.ImportDeclaration{
+		ImportGroups: []types.ImportGroup{missing},
+	})
+}
+
+// importSpecName returns the name under which the import is available in the file.
+func importSpecName(s *ast.ImportSpec) string {
+	if s.Name != nil {
+		return s.Name.Name
+	}
+	specPath, _ := strconv.Unquote(s.Path.Value)
+	return assumedPackageName(specPath)
+}
+
+// assumedPackageName returns the package name an import path is assumed to have
+// when it is imported without an alias, following the same conventions as goimports.
+func assumedPackageName(importPath string) string {
+	if stdlib.IsStdlib(importPath) {
+		return stdlib.PackageName(importPath)
+	}
+
+	base := path.Base(importPath)
+	if stdlib.IsMajorVersionSuffix(base) && path.Dir(importPath) != "." {
+		base = path.Base(path.Dir(importPath))
+	}
+	base = strings.TrimPrefix(base, "go-")
+	if i := strings.IndexAny(base, ".-"); i >= 0 {
+		base = base[:i]
+	}
+	return base
+}
+
+func makeImportSpec(name string, importPath string) *ast.ImportSpec {
+	spec := &ast.ImportSpec{
+		Path: &ast.BasicLit{
+			Kind:  token.STRING,
+			Value: strconv.Quote(importPath),
+		},
+	}
+	if assumedPackageName(importPath) != name {
+		spec.Name = &ast.Ident{Name: name}
+	}
+	return spec
+}
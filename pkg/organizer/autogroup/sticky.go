@@ -0,0 +1,92 @@
+package autogroup
+
+// StickyGroupPlacement controls where sticky (doc commented) groups and declarations are
+// placed relative to the automatically organized ones.
+type StickyGroupPlacement int
+
+const (
+	// StickyGroupsAfter places sticky groups after automatically organized groups.
+	StickyGroupsAfter StickyGroupPlacement = iota
+	// StickyGroupsBefore places sticky groups before automatically organized groups.
+	StickyGroupsBefore
+	// StickyGroupsInterleaved keeps sticky groups at their original position relative to
+	// automatically organized groups: a sticky group that was preceded by N other groups
+	// is placed after the first N organized groups, sticky groups that were first or last
+	// stay first or last.
+	StickyGroupsInterleaved
+)
+
+// ParseStickyGroupPlacement parses placement name (e.g. from command line flags):
+// `after` (or empty string), `before` or `interleaved`.
+func ParseStickyGroupPlacement(name string) (StickyGroupPlacement, bool) {
+	switch name {
+	case "", "after":
+		return StickyGroupsAfter, true
+	case "before":
+		return StickyGroupsBefore, true
+	case "interleaved":
+		return StickyGroupsInterleaved, true
+	default:
+		return StickyGroupsAfter, false
+	}
+}
+
+// WithStickyGroupPlacement controls where sticky groups are placed relative to automatically
+// organized groups, both within and across import declarations. Defaults to [StickyGroupsAfter].
+func WithStickyGroupPlacement(placement StickyGroupPlacement) Option {
+	return func(conf *config) {
+		conf.stickyPlacement = placement
+	}
+}
+
+// WithStickyGroupSorting controls whether specs inside sticky groups are sorted (the default).
+// When disabled, specs of doc commented declarations keep their order as well, while still
+// being fixed up and grouped.
+// Sorting can also be disabled for individual groups with the `//gofancyimports:nosort`
+// directive in the group doc comment.
+func WithStickyGroupSorting(enable bool) Option {
+	return func(conf *config) {
+		conf.preserveStickyOrder = !enable
+	}
+}
+
+// stickyItem is a sticky group or declaration along with the number of automatically
+// organized items that preceded it in the source.
+type stickyItem[T any] struct {
+	item  T
+	after int
+}
+
+// placeSticky combines automatically organized items with sticky items according to the
+// configured placement. The autoTotal is the number of automatically organized items in the
+// source, before they were organized.
+func placeSticky[T any](placement StickyGroupPlacement, auto []T, sticky []stickyItem[T], autoTotal int) []T {
+	result := make([]T, 0, len(auto)+len(sticky))
+	switch placement {
+	case StickyGroupsBefore:
+		for _, s := range sticky {
+			result = append(result, s.item)
+		}
+		return append(result, auto...)
+	case StickyGroupsInterleaved:
+		next := 0
+		for _, s := range sticky {
+			pos := s.after
+			if pos >= autoTotal || pos > len(auto) {
+				pos = len(auto)
+			}
+			if pos > next {
+				result = append(result, auto[next:pos]...)
+				next = pos
+			}
+			result = append(result, s.item)
+		}
+		return append(result, auto[next:]...)
+	default:
+		result = append(result, auto...)
+		for _, s := range sticky {
+			result = append(result, s.item)
+		}
+		return result
+	}
+}
@@ -0,0 +1,124 @@
+package autogroup
+
+import (
+	"go/ast"
+	"path/filepath"
+	"strings"
+)
+
+// PackageUnderTestPlacement controls where the import of the package under test is placed
+// in external test packages (`package foo_test`).
+type PackageUnderTestPlacement int
+
+const (
+	// PackageUnderTestInline groups the package under test like any other import.
+	PackageUnderTestInline PackageUnderTestPlacement = iota
+	// PackageUnderTestLeading places the package under test in its own group before all other groups.
+	PackageUnderTestLeading
+	// PackageUnderTestTrailing places the package under test in its own group after all other groups.
+	PackageUnderTestTrailing
+)
+
+// ParsePackageUnderTestPlacement parses placement name (e.g. from command line flags):
+// `inline` (or empty string), `leading` or `trailing`.
+func ParsePackageUnderTestPlacement(name string) (PackageUnderTestPlacement, bool) {
+	switch strings.TrimSpace(name) {
+	case "", "inline":
+		return PackageUnderTestInline, true
+	case "leading":
+		return PackageUnderTestLeading, true
+	case "trailing":
+		return PackageUnderTestTrailing, true
+	default:
+		return PackageUnderTestInline, false
+	}
+}
+
+// DefaultTestHelperPackages lists packages that are only used by tests, see [WithTestHelperGroup].
+var DefaultTestHelperPackages = []string{
+	"testing/...",
+	"net/http/httptest",
+	"github.com/stretchr/testify/...",
+	"github.com/golang/mock/...",
+	"go.uber.org/mock/...",
+	"github.com/google/go-cmp/...",
+	"github.com/onsi/ginkgo/...",
+	"github.com/onsi/gomega/...",
+	"gotest.tools/...",
+}
+
+// WithPackageUnderTestGroup places the import of the package under test in its own group
+// in files of external test packages (`package foo_test`).
+//
+// The package under test is the package at the path provided by [WithPackagePath], or when
+// it is not provided, the import whose assumed package name is the test package name without
+// its `_test` suffix.
+//
+// Requires [WithFile].
+func WithPackageUnderTestGroup(placement PackageUnderTestPlacement) Option {
+	return func(conf *config) {
+		conf.packageUnderTest = placement
+	}
+}
+
+// WithPackagePath provides the import path of the package the organized file belongs to.
+// For external test packages this is the path of the package under test.
+func WithPackagePath(importPath string) Option {
+	return func(conf *config) {
+		conf.packagePath = strings.TrimSuffix(importPath, "_test")
+	}
+}
+
+// WithTestHelperGroup enables an extra group for packages only used by tests (`testing`,
+// `testify`, `gomock`, ...) in `_test.go` files. Other files are not affected.
+//
+// Requires [WithFile].
+func WithTestHelperGroup(enable bool) Option {
+	return func(conf *config) {
+		conf.groupTestHelpers = enable
+	}
+}
+
+// WithTestHelperPackages overrides [DefaultTestHelperPackages]. Entries ending with `/...`
+// match the path itself and every path below it.
+func WithTestHelperPackages(patterns []string) Option {
+	return func(conf *config) {
+		conf.testHelperPackages = patterns
+	}
+}
+
+// isTestFile reports whether the organized file is a `_test.go` file.
+func (org *organizer) isTestFile() bool {
+	if org.config.fset == nil || org.config.file == nil {
+		return false
+	}
+	filename := org.config.fset.Position(org.config.file.Package).Filename
+	return strings.HasSuffix(filepath.Base(filename), "_test.go")
+}
+
+func (org *organizer) isPackageUnderTestImport(s *ast.ImportSpec, specPath string) bool {
+	if org.config.file == nil || isSideEffectImport(s) {
+		return false
+	}
+	testedName, ok := strings.CutSuffix(org.config.file.Name.Name, "_test")
+	if !ok {
+		return false
+	}
+	if org.config.packagePath != "" {
+		return specPath == org.config.packagePath
+	}
+	return (s.Name == nil || s.Name.Name == testedName) && assumedPackageName(specPath) == testedName
+}
+
+func (org *organizer) isTestHelperImport(specPath string) bool {
+	patterns := org.config.testHelperPackages
+	if patterns == nil {
+		patterns = DefaultTestHelperPackages
+	}
+	for _, pattern := range patterns {
+		if matchPathPattern(pattern, specPath) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,67 @@
+package types
+
+import (
+	"go/ast"
+	"strings"
+)
+
+// Directives recognized in comments. Directives are written as line comments, with or
+// without a space after the slashes (e.g. `//gofancyimports:keep`).
+const (
+	// DirectivePrefix is the common prefix of all directives.
+	DirectivePrefix = "gofancyimports:"
+
+	// DirectiveOff disables import organization of the whole file when placed before the
+	// package clause or the first declaration.
+	DirectiveOff = DirectivePrefix + "off"
+	// DirectiveKeep placed on an import declaration leaves the declaration untouched.
+	DirectiveKeep = DirectivePrefix + "keep"
+	// DirectiveNoSort placed in a group doc comment preserves the order of specs in the group.
+	DirectiveNoSort = DirectivePrefix + "nosort"
+	// DirectiveGroup placed as a line comment on an import spec forces the spec into
+	// the named group (e.g. `//gofancyimports:group=std`).
+	DirectiveGroup = DirectivePrefix + "group="
+)
+
+// CommentDirectives returns all directives found in the comment group, with surrounding
+// whitespace trimmed (e.g. `gofancyimports:group=std`).
+func CommentDirectives(cg *ast.CommentGroup) []string {
+	if cg == nil {
+		return nil
+	}
+
+	var directives []string
+	for _, c := range cg.List {
+		text, ok := strings.CutPrefix(c.Text, "//")
+		if !ok {
+			continue
+		}
+		if text = strings.TrimSpace(text); strings.HasPrefix(text, DirectivePrefix) {
+			directives = append(directives, text)
+		}
+	}
+	return directives
+}
+
+// HasDirective reports whether the comment group contains the directive.
+func HasDirective(cg *ast.CommentGroup, directive string) bool {
+	for _, d := range CommentDirectives(cg) {
+		if d == directive {
+			return true
+		}
+	}
+	return false
+}
+
+// DirectiveValue returns the value of the first directive with the provided prefix (e.g.
+// [DirectiveGroup]) found in the comment group. Text following the value is ignored.
+func DirectiveValue(cg *ast.CommentGroup, prefix string) (string, bool) {
+	for _, d := range CommentDirectives(cg) {
+		if value, ok := strings.CutPrefix(d, prefix); ok {
+			if fields := strings.Fields(value); len(fields) > 0 {
+				return fields[0], true
+			}
+		}
+	}
+	return "", false
+}
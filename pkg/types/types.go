@@ -2,13 +2,35 @@ package types
 
 import (
 	"go/ast"
-	"strings"
+	"go/token"
 )
 
 // ImportTransform is a function that allows reordering merging and splitting
 // existing []ImportDeclaration obtained from source.
 type ImportTransform func(decls []ImportDeclaration) []ImportDeclaration
 
+// PackageTransform is the counterpart of ImportTransform operating on every file of a
+// package at once, allowing to enforce consistency across files (e.g. the same alias for
+// the same path). It returns the transformed declarations of every file, in the order of
+// files.
+type PackageTransform func(fset *token.FileSet, files []PackageFile) [][]ImportDeclaration
+
+// PackageFile represents a single file of a package handed to a PackageTransform.
+type PackageFile struct {
+	// Filename is the name of the file as recorded in the file set.
+	Filename string
+
+	// File is the parsed file, to be inspected beyond its import declarations.
+	File *ast.File
+
+	// Decls contains the import declarations of the file.
+	Decls []ImportDeclaration
+
+	// Disabled is set when the file opted out of import rewriting, transformed
+	// declarations of such files are discarded.
+	Disabled bool
+}
+
 // ImportDeclaration represents a single import block. (i.e. the contents of the `import` statement)
 type ImportDeclaration struct {
 	// LeadingComments comments that are floating above this declaration,
@@ -22,8 +44,24 @@ type ImportDeclaration struct {
 	// Doc is the doc comment for this import declaration.
 	Doc *ast.CommentGroup
 
+	// CommentAnchors maps LeadingComments and DetachedComments to the spec that followed
+	// them in the source. Comments that were not followed by any spec have no anchor.
+	CommentAnchors map[*ast.CommentGroup]*ast.ImportSpec
+
 	// ImportGroups contains the list of underlying ast.ImportSpec-s.
 	ImportGroups []ImportGroup
+
+	// Parenthesized requests the declaration to be written with parenthesis even if it
+	// contains a single import spec.
+	Parenthesized bool
+
+	// Keep is set when the declaration is marked with the `//gofancyimports:keep` directive,
+	// requesting that the declaration is left untouched.
+	Keep bool
+
+	// IdentRenames maps identifiers of the file outside of import declarations to their new
+	// names, e.g. qualifiers of references to a package whose import got aliased by the transform.
+	IdentRenames map[*ast.Ident]string
 }
 
 // ImportGroup maps to set of consecutive import specs delimited by
@@ -34,12 +72,27 @@ type ImportDeclaration struct {
 //
 // Contained within an ImportDeclaration.
 type ImportGroup struct {
+	// LeadingComments are comments floating inside the declaration above this group,
+	// separated from the group by a blank line.
+	LeadingComments []*ast.CommentGroup
+
 	Doc   *ast.CommentGroup
 	Specs []*ast.ImportSpec
+
+	// NoSort is set when the group doc comment contains the `//gofancyimports:nosort`
+	// directive, requesting that the order of specs is preserved.
+	NoSort bool
+
+	// SpecGroups maps specs marked with the `//gofancyimports:group=<name>` line comment
+	// directive to the name of the group they are forced into.
+	SpecGroups map[*ast.ImportSpec]string
 }
 
 // MergeDeclarations returns two or more ImportDeclarations merged together
 // by appending import groups and comment sections together.
+//
+// Only the doc comment of the first declaration having one is kept, transforms
+// that should keep all of them can use [MergeDeclarationDocs].
 func MergeDeclarations(decls []ImportDeclaration) ImportDeclaration {
 	var merged ImportDeclaration
 
@@ -49,9 +102,24 @@ func MergeDeclarations(decls []ImportDeclaration) ImportDeclaration {
 			merged.Doc = d.Doc
 		}
 
+		merged.Keep = merged.Keep || d.Keep
+		merged.Parenthesized = merged.Parenthesized || d.Parenthesized
 		merged.LeadingComments = append(merged.LeadingComments, d.LeadingComments...)
 		merged.DetachedComments = append(merged.DetachedComments, d.DetachedComments...)
 
+		for ident, name := range d.IdentRenames {
+			if merged.IdentRenames == nil {
+				merged.IdentRenames = map[*ast.Ident]string{}
+			}
+			merged.IdentRenames[ident] = name
+		}
+		for cg, spec := range d.CommentAnchors {
+			if merged.CommentAnchors == nil {
+				merged.CommentAnchors = map[*ast.CommentGroup]*ast.ImportSpec{}
+			}
+			merged.CommentAnchors[cg] = spec
+		}
+
 		groups = append(groups, d.ImportGroups...)
 	}
 
@@ -59,6 +127,16 @@ func MergeDeclarations(decls []ImportDeclaration) ImportDeclaration {
 	return merged
 }
 
+// MergeDeclarationDocs returns the doc comments of all declarations merged into
+// one (see [MergeDocComments]), e.g. to document declarations merged together.
+func MergeDeclarationDocs(decls []ImportDeclaration) *ast.CommentGroup {
+	docs := make([]*ast.CommentGroup, 0, len(decls))
+	for _, d := range decls {
+		docs = append(docs, d.Doc)
+	}
+	return MergeDocComments(docs)
+}
+
 // MergeGroups returns two or more ImportGroups merged together
 // by appending their import specs and comments together and.
 func MergeGroups(groups []ImportGroup) ImportGroup {
@@ -68,26 +146,85 @@ func MergeGroups(groups []ImportGroup) ImportGroup {
 		if g.Doc != nil {
 			docCommets = append(docCommets, g.Doc)
 		}
+		merged.LeadingComments = append(merged.LeadingComments, g.LeadingComments...)
 		merged.Specs = append(merged.Specs, g.Specs...)
+
+		for s, name := range g.SpecGroups {
+			if merged.SpecGroups == nil {
+				merged.SpecGroups = map[*ast.ImportSpec]string{}
+			}
+			merged.SpecGroups[s] = name
+		}
 	}
 	merged.Doc = MergeDocComments(docCommets)
 	return merged
 }
 
-// MergeDocComments returns multiple doc comment groups merged into one.
+// MergeDocComments returns multiple doc comment groups merged into one, preserving
+// every original comment (including its kind and position), so that blank lines that
+// separated the merged groups in the source can be preserved when the result is written.
 func MergeDocComments(groups []*ast.CommentGroup) *ast.CommentGroup {
-	var docText []string
+	var list []*ast.Comment
 	for _, g := range groups {
-		for _, c := range g.List {
-			docText = append(docText, c.Text)
+		if g == nil {
+			continue
 		}
+		list = append(list, g.List...)
 	}
-	if len(docText) == 0 {
+	if len(list) == 0 {
 		return nil
 	}
-	return &ast.CommentGroup{
-		List: []*ast.Comment{
-			{Text: strings.Join(docText, "\n")},
-		},
+	return &ast.CommentGroup{List: list}
+}
+
+// DedupDocComments returns the comment group without comments repeating the text of a comment
+// of an earlier source comment group it was merged from (e.g. the same `// nolint:depguard` of
+// two merged groups).
+//
+// Source comment groups are told apart by the lines reported by lineOf, and repeated comments
+// are only trimmed from the start and end of a source group. Comments inside a source group
+// are never removed, so blank comment lines separating paragraphs survive and no gaps appear
+// inside the doc comment. Without lineOf the comment group is returned as is.
+func DedupDocComments(group *ast.CommentGroup, lineOf func(token.Pos) int) *ast.CommentGroup {
+	if group == nil || lineOf == nil {
+		return group
+	}
+
+	seen := map[string]bool{}
+	var list []*ast.Comment
+	for _, source := range sourceCommentGroups(group.List, lineOf) {
+		start, end := 0, len(source)
+		for start < end && seen[source[start].Text] {
+			start++
+		}
+		for end > start && seen[source[end-1].Text] {
+			end--
+		}
+		for _, c := range source {
+			seen[c.Text] = true
+		}
+		list = append(list, source[start:end]...)
+	}
+	if len(list) == 0 {
+		return nil
+	}
+	return &ast.CommentGroup{List: list}
+}
+
+// sourceCommentGroups splits merged comments into the comment groups they came from: runs of
+// comments on consecutive source lines. Comments with unknown lines (e.g. copied without
+// positions) are kept together in runs of their own.
+func sourceCommentGroups(comments []*ast.Comment, lineOf func(token.Pos) int) [][]*ast.Comment {
+	var groups [][]*ast.Comment
+	for i, c := range comments {
+		if i > 0 {
+			prevEnd, start := lineOf(comments[i-1].End()), lineOf(c.Pos())
+			if (prevEnd == 0 && start == 0) || (prevEnd != 0 && start == prevEnd+1) {
+				groups[len(groups)-1] = append(groups[len(groups)-1], c)
+				continue
+			}
+		}
+		groups = append(groups, []*ast.Comment{c})
 	}
+	return groups
 }
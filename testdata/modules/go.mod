@@ -0,0 +1,5 @@
+module example.com/app
+
+go 1.22
+
+require github.com/acme/logx v1.0.0
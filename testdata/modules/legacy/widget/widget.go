@@ -0,0 +1,5 @@
+package widget
+
+type Widget struct{}
+
+func Old() *Widget { return &Widget{} }
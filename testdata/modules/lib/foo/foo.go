@@ -0,0 +1,3 @@
+package bar
+
+func X() int { return 0 }
@@ -0,0 +1,5 @@
+package widget
+
+type Widget struct{}
+
+func New() *Widget { return &Widget{} }